@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andrearcaina/pathfinder/pkg/pathfinder"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigName is the config file looked up in the scan root when --config isn't set.
+const projectConfigName = ".pathfinder.yaml"
+
+// projectConfig is the on-disk shape of .pathfinder.yaml. Every key maps onto a scan flag of the same name.
+type projectConfig struct {
+	Path                 string         `yaml:"path"`
+	Recursive            *bool          `yaml:"recursive"`
+	MaxDepth             *int           `yaml:"max-depth"`
+	Hidden               *bool          `yaml:"hidden"`
+	NoIgnore             *bool          `yaml:"no-ignore"`
+	Exclude              []string       `yaml:"exclude"`
+	Include              []string       `yaml:"include"`
+	NoDefaultExcludes    *bool          `yaml:"no-default-excludes"`
+	IncludeVendored      *bool          `yaml:"include-vendored"`
+	TestPatterns         []string       `yaml:"test-pattern"`
+	IncludeGenerated     *bool          `yaml:"include-generated"`
+	DocstringsAsComments *bool          `yaml:"docstrings-as-comments"`
+	MixedLines           string         `yaml:"mixed-lines"`
+	AnnotationTags       []string       `yaml:"annotation-tags"`
+	BlameAnnotations     *bool          `yaml:"blame-annotations"`
+	MaxAnnotationAge     string         `yaml:"max-annotation-age"`
+	Languages            []string       `yaml:"languages"`
+	BufferSize           *int           `yaml:"buffer-size"`
+	Workers              *int           `yaml:"workers"`
+	Dependencies         *bool          `yaml:"dependencies"`
+	Git                  *bool          `yaml:"git"`
+	GoMetrics            *bool          `yaml:"go-metrics"`
+	Functions            *bool          `yaml:"functions"`
+	Duplicates           *bool          `yaml:"duplicates"`
+	MinDuplicateLines    *int           `yaml:"min-duplicate-lines"`
+	Throughput           *bool          `yaml:"throughput"`
+	FailFast             *bool          `yaml:"fail-fast"`
+	Timeout              *time.Duration `yaml:"timeout"`
+	Output               struct {
+		Format string `yaml:"format"`
+		File   string `yaml:"file"`
+	} `yaml:"output"`
+}
+
+// loadProjectConfig reads the config file passed with --config, or .pathfinder.yaml in the scan root if it exists.
+// The returned path is empty when no config file was used.
+func loadProjectConfig(explicitPath, scanRoot string) (projectConfig, string, error) {
+	path := explicitPath
+	if path == "" {
+		path = filepath.Join(scanRoot, projectConfigName)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if explicitPath == "" && errors.Is(err, fs.ErrNotExist) {
+			return projectConfig{}, "", nil
+		}
+		return projectConfig{}, "", fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var config projectConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true) // catch typos like "max_depth" instead of silently ignoring them
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return projectConfig{}, "", fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// relative paths in the file are resolved against the directory the file lives in
+	if config.Path != "" && !filepath.IsAbs(config.Path) {
+		config.Path = filepath.Join(filepath.Dir(path), config.Path)
+	}
+	for i, languagesFile := range config.Languages {
+		if !filepath.IsAbs(languagesFile) {
+			config.Languages[i] = filepath.Join(filepath.Dir(path), languagesFile)
+		}
+	}
+	if config.Output.File != "" && !filepath.IsAbs(config.Output.File) {
+		config.Output.File = filepath.Join(filepath.Dir(path), config.Output.File)
+	}
+
+	return config, path, nil
+}
+
+// applyProjectConfig copies values from the config file onto the scan flags the user didn't set explicitly,
+// so command line flags always take precedence over the file.
+func applyProjectConfig(cmd *cobra.Command, config projectConfig) {
+	flags := cmd.Flags()
+	setString := func(name string, dst *string, value string) {
+		if value != "" && !flags.Changed(name) {
+			*dst = value
+		}
+	}
+	setStrings := func(name string, dst *[]string, value []string) {
+		if value != nil && !flags.Changed(name) {
+			*dst = value
+		}
+	}
+	setBool := func(name string, dst *bool, value *bool) {
+		if value != nil && !flags.Changed(name) {
+			*dst = *value
+		}
+	}
+	setInt := func(name string, dst *int, value *int) {
+		if value != nil && !flags.Changed(name) {
+			*dst = *value
+		}
+	}
+
+	setString("path", &pathFlag, config.Path)
+	setBool("recursive", &recursiveFlag, config.Recursive)
+	setInt("max-depth", &maxDepthFlag, config.MaxDepth)
+	setBool("hidden", &hiddenFlag, config.Hidden)
+	setBool("no-ignore", &noIgnoreFlag, config.NoIgnore)
+	setStrings("exclude", &excludeFlag, config.Exclude)
+	setStrings("include", &includeFlag, config.Include)
+	setBool("no-default-excludes", &noDefaultsFlag, config.NoDefaultExcludes)
+	setBool("include-vendored", &includeVendoredFlag, config.IncludeVendored)
+	setBool("include-generated", &includeGeneratedFlag, config.IncludeGenerated)
+	setStrings("test-pattern", &testPatternsFlag, config.TestPatterns)
+	setStrings("languages", &languagesFlag, config.Languages)
+	setBool("docstrings-as-comments", &docstringsAsCommentsFlag, config.DocstringsAsComments)
+	setString("mixed-lines", &mixedLinesFlag, config.MixedLines)
+	setStrings("annotation-tags", &annotationTagsFlag, config.AnnotationTags)
+	setBool("blame-annotations", &blameAnnotationsFlag, config.BlameAnnotations)
+	setString("max-annotation-age", &maxAnnotationAgeFlag, config.MaxAnnotationAge)
+	setInt("buffer-size", &bufferSizeFlag, config.BufferSize)
+	setInt("workers", &workerFlag, config.Workers)
+	setBool("dependencies", &dependencyFlag, config.Dependencies)
+	setBool("git", &gitFlag, config.Git)
+	setBool("go-metrics", &goMetricsFlag, config.GoMetrics)
+	setBool("functions", &functionMetricsFlag, config.Functions)
+	setBool("duplicates", &duplicatesFlag, config.Duplicates)
+	setInt("min-duplicate-lines", &minDuplicateLinesFlag, config.MinDuplicateLines)
+	setBool("throughput", &throughputFlag, config.Throughput)
+	setBool("fail-fast", &failFastFlag, config.FailFast)
+	if config.Timeout != nil && !flags.Changed("timeout") {
+		timeoutFlag = *config.Timeout
+	}
+	setString("format", &formatFlag, config.Output.Format)
+	setString("output", &outputFlag, config.Output.File)
+}
+
+// scanConfig builds the library config from the (possibly config file populated) scan flags,
+// loading the custom language files they point to and checking the flags the library doesn't see.
+func scanConfig() (pathfinder.Config, error) {
+	config := pathfinder.Config{
+		PathFlag:                 pathFlag,
+		HiddenFlag:               hiddenFlag,
+		NoIgnoreFlag:             noIgnoreFlag,
+		ExcludeFlag:              excludeFlag,
+		IncludeFlag:              includeFlag,
+		BufferSizeFlag:           bufferSizeFlag,
+		RecursiveFlag:            recursiveFlag,
+		MaxDepthFlag:             maxDepthFlag,
+		DependencyFlag:           dependencyFlag,
+		GitFlag:                  gitFlag,
+		GoMetricsFlag:            goMetricsFlag,
+		FunctionMetricsFlag:      functionMetricsFlag,
+		DuplicatesFlag:           duplicatesFlag,
+		MinDuplicateLinesFlag:    minDuplicateLinesFlag,
+		WorkerFlag:               workerFlag,
+		ThroughputFlag:           throughputFlag,
+		FailFastFlag:             failFastFlag,
+		NoDefaultExcludesFlag:    noDefaultsFlag,
+		IncludeVendoredFlag:      includeVendoredFlag,
+		TestPatternsFlag:         testPatternsFlag,
+		IncludeGeneratedFlag:     includeGeneratedFlag,
+		DocstringsAsCommentsFlag: docstringsAsCommentsFlag,
+		MixedLinesFlag:           pathfinder.MixedLineMode(mixedLinesFlag),
+		AnnotationTagsFlag:       annotationTagsFlag,
+		BlameAnnotationsFlag:     blameAnnotationsFlag || maxAnnotationAgeFlag != "",
+	}
+
+	if maxAnnotationAgeFlag != "" {
+		if _, err := parseAge(maxAnnotationAgeFlag); err != nil {
+			return pathfinder.Config{}, err
+		}
+	}
+
+	for _, languagesFile := range languagesFlag {
+		languages, err := pathfinder.LoadLanguages(languagesFile)
+		if err != nil {
+			return pathfinder.Config{}, err
+		}
+		config.Languages = append(config.Languages, languages...)
+	}
+	return config, nil
+}
+
+// defaultProjectConfig is written by `pathfinder init`. Values mirror the scan flag defaults.
+const defaultProjectConfig = `# Pathfinder project configuration.
+# Every key maps onto a "pathfinder scan" flag of the same name and flags passed on the
+# command line always override the values set here.
+
+# Path to scan, relative to this file.
+path: .
+
+# Scan subdirectories, optionally limited to a maximum depth (-1 means no limit).
+recursive: false
+max-depth: -1
+
+# Include hidden files and directories (starting with .).
+hidden: false
+
+# Don't respect .gitignore, .ignore and .git/info/exclude files.
+no-ignore: false
+
+# Doublestar globs of files or directories to skip, relative to the scan path.
+# A pattern without a slash matches names at any depth.
+exclude: []
+#  - "**/*_generated.go"
+#  - "docs/**"
+
+# Doublestar globs of files to scan. If set, everything else is skipped.
+include: []
+
+# Replace the built-in excludes (node_modules, vendor, go.sum, ...) with the patterns above.
+no-default-excludes: false
+
+# Scan vendored directories (vendor, node_modules, ...) despite the built-in excludes, counted as vendored code.
+include-vendored: false
+
+# Count generated files ("Code generated ... DO NOT EDIT.", "@generated"), minified code and lockfiles
+# like any other file. By default they are left out of the totals and listed separately.
+include-generated: false
+
+# Doublestar globs of test files, matched like exclude. Defaults to the conventions of common languages
+# (_test.go, test_*.py, *.spec.ts, src/test/java, __tests__/, ...).
+test-pattern: []
+#  - "e2e/**"
+
+# JSON or YAML files with custom language definitions, relative to this file, e.g.
+#   - name: Pipeline
+#     comments: {line: ["#"]}
+#     extensions: [.pipeline]
+languages: []
+
+# Count docstring lines (e.g. Python's """...""") as comments instead of separately.
+docstrings-as-comments: false
+
+# Where lines with both code and a comment (e.g. "x := 1 // set x") are counted: code, comment or both.
+mixed-lines: code
+
+# Comment tags counted as annotations, matched as whole words. Defaults to TODO, FIXME and HACK.
+annotation-tags:
+  - TODO
+  - FIXME
+  - HACK
+#  - XXX
+#  - BUG
+
+# Run git blame on annotations to report their author and age, and fail the scan if one is older
+# than max-annotation-age (e.g. 90d, 2y). Setting max-annotation-age implies blame-annotations.
+blame-annotations: false
+max-annotation-age: ""
+
+# Buffer size for reading files in KB. Options are 4, 8, 16, 32, 64.
+buffer-size: 4
+
+# Number of concurrent workers used for scanning files.
+workers: 16
+
+# Extra analysis.
+dependencies: false
+git: false
+go-metrics: false
+functions: false
+throughput: false
+
+# Find identical files and duplicated blocks of at least min-duplicate-lines code lines.
+# Blocks of min-duplicate-lines + 2 lines or more are always found, shorter ones can be missed.
+duplicates: false
+min-duplicate-lines: 6
+
+# Stop at the first file that can't be read instead of skipping it.
+fail-fast: false
+
+# Stop the scan after this long and report partial results (e.g. 30s, 5m). 0 means no timeout.
+timeout: 0s
+
+# Export the report instead of printing it, to a file relative to this file. Both keys must be set together.
+output:
+  format: ""
+  file: ""
+`
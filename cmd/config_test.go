@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyProjectConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   string
+		args   []string
+		config string
+		got    func() any
+		want   any
+	}{
+		{name: "file sets an unset flag", flag: "max-depth", config: "max-depth: 3\n", got: func() any { return maxDepthFlag }, want: 3},
+		{name: "flag wins over the file", flag: "max-depth", args: []string{"--max-depth", "1"}, config: "max-depth: 3\n", got: func() any { return maxDepthFlag }, want: 1},
+		{name: "flag set to its default wins", flag: "max-depth", args: []string{"--max-depth=-1"}, config: "max-depth: 3\n", got: func() any { return maxDepthFlag }, want: -1},
+		{name: "false bool flag wins", flag: "recursive", args: []string{"--recursive=false"}, config: "recursive: true\n", got: func() any { return recursiveFlag }, want: false},
+		{name: "list flag replaces the file list", flag: "exclude", args: []string{"-e", "docs/**"}, config: "exclude: [\"vendor/**\", \"tmp/**\"]\n", got: func() any { return excludeFlag }, want: []string{"docs/**"}},
+		{name: "file list", flag: "exclude", config: "exclude: [\"vendor/**\"]\n", got: func() any { return excludeFlag }, want: []string{"vendor/**"}},
+		{name: "timeout flag wins", flag: "timeout", args: []string{"--timeout", "5s"}, config: "timeout: 1m\n", got: func() any { return timeoutFlag }, want: 5 * time.Second},
+		{name: "output flag wins", flag: "output", args: []string{"-o", "cli.json"}, config: "output:\n  file: file.json\n", got: func() any { return outputFlag }, want: "cli.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetScanFlag(t, tt.flag)
+			if err := scanCmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+
+			path := filepath.Join(t.TempDir(), projectConfigName)
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			config, _, err := loadProjectConfig(path, ".")
+			if err != nil {
+				t.Fatalf("loadProjectConfig() error = %v", err)
+			}
+			applyProjectConfig(scanCmd, config)
+
+			if got := tt.got(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("--%s = %v, want %v", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+// resetScanFlag puts a scan flag back to its default and unset state once the test is done,
+// as the flags are package-level and shared by every test.
+func resetScanFlag(t *testing.T, name string) {
+	flag := scanCmd.Flags().Lookup(name)
+	if flag == nil {
+		t.Fatalf("scan has no --%s flag", name)
+	}
+	t.Cleanup(func() {
+		if list, ok := flag.Value.(interface{ Replace([]string) error }); ok {
+			_ = list.Replace(nil)
+		} else {
+			_ = flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	})
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	absolute := filepath.Join(t.TempDir(), "languages.yaml")
+
+	tests := []struct {
+		name     string
+		config   string
+		noFile   bool
+		explicit bool
+		want     func(dir string) projectConfig
+		wantErr  string
+	}{
+		{
+			name:     "relative paths resolve against the file",
+			config:   "path: src\nlanguages: [langs.yaml, " + absolute + "]\noutput:\n  format: json\n  file: out/report.json\n",
+			explicit: true,
+			want: func(dir string) projectConfig {
+				var config projectConfig
+				config.Path = filepath.Join(dir, "src")
+				config.Languages = []string{filepath.Join(dir, "langs.yaml"), absolute}
+				config.Output.Format = "json"
+				config.Output.File = filepath.Join(dir, "out", "report.json")
+				return config
+			},
+		},
+		{name: "unknown key", config: "max_depth: 3\n", explicit: true, wantErr: "field max_depth not found"},
+		{name: "empty file", explicit: true},
+		{name: "no file in the scan root", noFile: true},
+		{name: "missing explicit file", noFile: true, explicit: true, wantErr: "failed to read config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, projectConfigName)
+			if !tt.noFile {
+				if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			explicitPath := ""
+			if tt.explicit {
+				explicitPath = path
+			}
+
+			config, configPath, err := loadProjectConfig(explicitPath, dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadProjectConfig() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadProjectConfig() error = %v", err)
+			}
+
+			wantPath := path
+			if tt.noFile {
+				wantPath = ""
+			}
+			if configPath != wantPath {
+				t.Fatalf("loadProjectConfig() path = %q, want %q", configPath, wantPath)
+			}
+			var want projectConfig
+			if tt.want != nil {
+				want = tt.want(dir)
+			}
+			if !reflect.DeepEqual(config, want) {
+				t.Fatalf("loadProjectConfig() = %+v, want %+v", config, want)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90d", want: 90 * 24 * time.Hour},
+		{value: "12w", want: 12 * 7 * 24 * time.Hour},
+		{value: "2y", want: 2 * 365 * 24 * time.Hour},
+		{value: "720h", want: 720 * time.Hour},
+		{value: "0d"},
+		{value: "-5d", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAge(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAge(%q) = %s, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("parseAge(%q) = %s, %v, want %s", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
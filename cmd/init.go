@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	initPathFlag  string
+	initForceFlag bool
+)
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "init writes a commented default .pathfinder.yaml config file",
+	Long: `init writes a commented default .pathfinder.yaml config file that "pathfinder scan" picks up
+from the scan root. Examples are:
+
+pathfinder init
+pathfinder init -p /path/to/codebase
+pathfinder init --force
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := filepath.Join(initPathFlag, projectConfigName)
+
+		if _, err := os.Stat(path); err == nil && !initForceFlag {
+			return fmt.Errorf("%s already exists. Use --force to overwrite it", path)
+		}
+
+		if err := os.WriteFile(path, []byte(defaultProjectConfig), 0644); err != nil {
+			return fmt.Errorf("failed to write config file %s: %w", path, err)
+		}
+
+		fmt.Println("Config written to " + path)
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initPathFlag, "path", "p", ".", "Directory to write the config file to")
+	initCmd.Flags().BoolVarP(&initForceFlag, "force", "", false, "Overwrite an existing config file")
+}
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/andrearcaina/pathfinder/internal/export"
 	"github.com/andrearcaina/pathfinder/internal/ui"
@@ -13,18 +18,39 @@ import (
 )
 
 var (
-	debugFlag      bool
-	pathFlag       string
-	hiddenFlag     bool
-	bufferSizeFlag int
-	recursiveFlag  bool
-	maxDepthFlag   int
-	formatFlag     string
-	outputFlag     string
-	dependencyFlag bool
-	gitFlag        bool
-	workerFlag     int
-	throughputFlag bool
+	debugFlag                bool
+	configFlag               string
+	pathFlag                 string
+	hiddenFlag               bool
+	noIgnoreFlag             bool
+	excludeFlag              []string
+	includeFlag              []string
+	noDefaultsFlag           bool
+	bufferSizeFlag           int
+	recursiveFlag            bool
+	maxDepthFlag             int
+	formatFlag               string
+	outputFlag               string
+	dependencyFlag           bool
+	gitFlag                  bool
+	workerFlag               int
+	throughputFlag           bool
+	failFastFlag             bool
+	timeoutFlag              time.Duration
+	noProgressFlag           bool
+	languagesFlag            []string
+	docstringsAsCommentsFlag bool
+	mixedLinesFlag           string
+	annotationTagsFlag       []string
+	blameAnnotationsFlag     bool
+	maxAnnotationAgeFlag     string
+	includeGeneratedFlag     bool
+	includeVendoredFlag      bool
+	goMetricsFlag            bool
+	functionMetricsFlag      bool
+	testPatternsFlag         []string
+	duplicatesFlag           bool
+	minDuplicateLinesFlag    int
 )
 
 // scanCmd represents the scan command
@@ -38,59 +64,148 @@ pathfinder scan -R
 pathfinder scan -p /path/to/codebase
 pathfinder scan -p /path/to/codebase -R -m 3 -i -b 16
 pathfinder scan -p /path/to/codebase -R -m 3 -f json -o report.json,
+pathfinder scan -R -e '**/*_generated.go' -e 'docs/**'
+pathfinder scan -c /path/to/.pathfinder.yaml
+pathfinder scan --languages languages.yaml
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		report, err := pathfinder.Scan(pathfinder.Config{
-			PathFlag:       pathFlag,
-			HiddenFlag:     hiddenFlag,
-			BufferSizeFlag: bufferSizeFlag,
-			RecursiveFlag:  recursiveFlag,
-			MaxDepthFlag:   maxDepthFlag,
-			DependencyFlag: dependencyFlag,
-			GitFlag:        gitFlag,
-			WorkerFlag:     workerFlag,
-			ThroughputFlag: throughputFlag,
-		})
+		projectConfig, configPath, err := loadProjectConfig(configFlag, pathFlag)
 		if err != nil {
 			return err
 		}
+		if configPath != "" {
+			applyProjectConfig(cmd, projectConfig)
+		}
 
-		if debugFlag { // print raw report for debugging (this is just printing the struct, not really "debugging")
-			fmt.Printf("Debug: %+v\n", report)
-			return nil
+		// Ctrl-C cancels the scan and still shows what was counted so far
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		if timeoutFlag > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeoutFlag)
+			defer cancel()
 		}
 
-		// validate that both format and output are set together
-		if (formatFlag != "" && outputFlag == "") || (outputFlag != "" && formatFlag == "") {
-			return errors.New("both --format and --output flags must be set together")
+		config, err := scanConfig()
+		if err != nil {
+			return err
 		}
 
-		// handle export if output and format flags are set
-		if outputFlag != "" && formatFlag != "" {
-			if strings.ToLower(filepath.Ext(outputFlag)) == "" {
-				return errors.New("output file must have an extension (e.g. .json)")
-			}
+		// live progress only makes sense in a terminal, and would skew the numbers in throughput mode
+		var progress *ui.ScanProgress
+		if !noProgressFlag && !throughputFlag && !debugFlag && ui.ProgressSupported() {
+			progress = ui.StartProgress()
+			config.OnProgress = progress.Update
+		}
 
-			formatFlag = strings.ToLower(formatFlag)
-			if formatFlag == "json" && strings.HasSuffix(outputFlag, ".json") {
-				if err := export.CreateJSON(report, outputFlag); err != nil {
-					return err
-				}
-				return nil
-			} else {
-				return fmt.Errorf("unsupported format '%s'. Supported formats: json", formatFlag)
-			}
+		report, scanErr := pathfinder.ScanContext(ctx, config)
+		if progress != nil {
+			progress.Stop()
 		}
+		if scanErr != nil && !report.Incomplete {
+			return scanErr
+		}
+		// the flags were valid, so errors from here on (a partial report, old annotations) don't need the usage text
+		cmd.SilenceUsage = true
 
-		ui.PrintReport(report, throughputFlag)
+		if err := writeReport(report); err != nil {
+			return err
+		}
+		if err := checkAnnotationAge(report.AnnotationMetrics); err != nil {
+			return err
+		}
+
+		if scanErr != nil {
+			if errors.Is(scanErr, context.DeadlineExceeded) {
+				return fmt.Errorf("scan timed out after %s, the report is partial", timeoutFlag)
+			}
+			return fmt.Errorf("scan interrupted (%w), the report is partial", scanErr)
+		}
 		return nil
 	},
 }
 
+// checkAnnotationAge fails when --max-annotation-age is set and an annotation was last changed before it.
+func checkAnnotationAge(metrics pathfinder.AnnotationMetrics) error {
+	if maxAnnotationAgeFlag == "" {
+		return nil
+	}
+	maxAge, err := parseAge(maxAnnotationAgeFlag)
+	if err != nil {
+		return err
+	}
+	if old := metrics.OlderThan(maxAge); len(old) > 0 {
+		return fmt.Errorf("%d annotations are older than %s, the oldest is %s:%d from %s",
+			len(old), maxAnnotationAgeFlag, old[0].Path, old[0].Line, old[0].Date.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// parseAge parses a duration like "90d", "12w" or "2y" on top of the units time.ParseDuration knows.
+func parseAge(value string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && n >= 0 {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid annotation age %q. Use a duration like 90d, 12w, 2y or 720h", value)
+	}
+	return age, nil
+}
+
+func writeReport(report pathfinder.CodebaseReport) error {
+	if debugFlag { // print raw report for debugging (this is just printing the struct, not really "debugging")
+		fmt.Printf("Debug: %+v\n", report)
+		return nil
+	}
+
+	// validate that both format and output are set together
+	if (formatFlag != "" && outputFlag == "") || (outputFlag != "" && formatFlag == "") {
+		return errors.New("both --format and --output flags must be set together")
+	}
+
+	// handle export if output and format flags are set
+	if outputFlag != "" && formatFlag != "" {
+		if strings.ToLower(filepath.Ext(outputFlag)) == "" {
+			return errors.New("output file must have an extension (e.g. .json)")
+		}
+
+		formatFlag = strings.ToLower(formatFlag)
+		if formatFlag == "json" && strings.HasSuffix(outputFlag, ".json") {
+			return export.CreateJSON(report, outputFlag)
+		} else {
+			return fmt.Errorf("unsupported format '%s'. Supported formats: json", formatFlag)
+		}
+	}
+
+	ui.PrintReport(report, throughputFlag)
+	return nil
+}
+
 func init() {
 	scanCmd.Flags().BoolVarP(&debugFlag, "debug", "", false, "Enable debug mode")
+	scanCmd.Flags().StringVarP(&configFlag, "config", "c", "", "Path to a config file. Defaults to .pathfinder.yaml in the scan path if it exists")
 	scanCmd.Flags().StringVarP(&pathFlag, "path", "p", ".", "Path to codebase/repository")
 	scanCmd.Flags().BoolVarP(&hiddenFlag, "hidden", "i", false, "Include hidden files and directories")
+	scanCmd.Flags().BoolVarP(&noIgnoreFlag, "no-ignore", "", false, "Don't respect .gitignore, .ignore and .git/info/exclude files")
+	scanCmd.Flags().StringArrayVarP(&excludeFlag, "exclude", "e", nil, "Glob of files or directories to skip (e.g. '**/*_generated.go'). Can be repeated")
+	scanCmd.Flags().StringArrayVarP(&includeFlag, "include", "", nil, "Glob of files to scan, skipping everything else (e.g. 'src/**'). Can be repeated")
+	scanCmd.Flags().StringArrayVarP(&languagesFlag, "languages", "", nil, "JSON or YAML file with custom language definitions. Can be repeated")
+	scanCmd.Flags().BoolVarP(&includeVendoredFlag, "include-vendored", "", false, "Scan vendored directories (e.g. vendor, node_modules) and count them as vendored code")
+	scanCmd.Flags().StringArrayVarP(&testPatternsFlag, "test-pattern", "", nil, "Glob of test files (e.g. 'e2e/**'), replacing the built-in conventions. Can be repeated")
+	scanCmd.Flags().BoolVarP(&includeGeneratedFlag, "include-generated", "", false, "Count generated files, minified code and lockfiles like any other file instead of separately")
+	scanCmd.Flags().BoolVarP(&noDefaultsFlag, "no-default-excludes", "", false, "Don't skip the built-in excludes (e.g. node_modules, vendor, go.sum)")
+	scanCmd.Flags().BoolVarP(&docstringsAsCommentsFlag, "docstrings-as-comments", "", false, "Count docstring lines as comments instead of separately")
+	scanCmd.Flags().StringSliceVarP(&annotationTagsFlag, "annotation-tags", "", nil, "Comment tags counted as annotations (e.g. TODO,FIXME,XXX). Defaults to TODO, FIXME and HACK")
+	scanCmd.Flags().BoolVarP(&blameAnnotationsFlag, "blame-annotations", "", false, "Run git blame on annotations to report their author and age, oldest first")
+	scanCmd.Flags().StringVarP(&maxAnnotationAgeFlag, "max-annotation-age", "", "", "Exit with an error if an annotation is older than this (e.g. 90d, 2y). Implies --blame-annotations")
+	scanCmd.Flags().StringVarP(&mixedLinesFlag, "mixed-lines", "", "code", "Where lines with both code and a comment are counted. Options are code, comment, both")
 	scanCmd.Flags().IntVarP(&bufferSizeFlag, "buffer-size", "b", 4, "Buffer size for reading files in KB. Options are 4, 8, 16, 32, 64")
 	scanCmd.Flags().BoolVarP(&recursiveFlag, "recursive", "R", false, "Scan directories recursively")
 	scanCmd.Flags().IntVarP(&maxDepthFlag, "max-depth", "m", -1, "Maximum recursion depth. Only works if --recursive is set")
@@ -98,6 +213,13 @@ func init() {
 	scanCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Sets output file name.")
 	scanCmd.Flags().BoolVarP(&dependencyFlag, "dependencies", "d", false, "Scan for dependencies (supported for some languages)")
 	scanCmd.Flags().BoolVarP(&gitFlag, "git", "g", false, "Scan for git information (e.g. number of commits, git history, etc.)")
+	scanCmd.Flags().BoolVarP(&duplicatesFlag, "duplicates", "", false, "Find identical files and duplicated blocks of code, and report how much of the code is duplicated")
+	scanCmd.Flags().IntVarP(&minDuplicateLinesFlag, "min-duplicate-lines", "", 6, "Minimum number of code lines in a reported duplicated block. Blocks of at least this many plus 2 lines are always found, shorter ones can be missed. Only works if --duplicates is set")
+	scanCmd.Flags().BoolVarP(&functionMetricsFlag, "functions", "", false, "Find functions in Go, Python, JavaScript, TypeScript and Java files and list the longest ones")
+	scanCmd.Flags().BoolVarP(&goMetricsFlag, "go-metrics", "", false, "Parse Go files to count packages, functions, types and tests, and find the most complex functions")
 	scanCmd.Flags().IntVarP(&workerFlag, "workers", "w", 16, "The total number of concurrent workers to use for scanning files")
+	scanCmd.Flags().BoolVarP(&failFastFlag, "fail-fast", "", false, "Stop at the first file that can't be read instead of skipping it")
+	scanCmd.Flags().DurationVarP(&timeoutFlag, "timeout", "", 0, "Stop the scan after this long and report partial results (e.g. 30s, 5m). 0 means no timeout")
+	scanCmd.Flags().BoolVarP(&noProgressFlag, "no-progress", "", false, "Don't show the live progress spinner while scanning")
 	scanCmd.Flags().BoolVarP(&throughputFlag, "throughput", "t", false, "Enable throughput mode to see scanning speed for each worker")
 }
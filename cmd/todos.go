@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/andrearcaina/pathfinder/internal/ui"
+	"github.com/andrearcaina/pathfinder/pkg/pathfinder"
+	"github.com/spf13/cobra"
+)
+
+var groupByFlag string
+
+// todosCmd represents the todos command
+var todosCmd = &cobra.Command{
+	Use:   "todos",
+	Short: "todos lists the annotations (TODO, FIXME, ...) found in a codebase",
+	Long: `todos lists the annotations (TODO, FIXME, ...) found in a codebase with their file and line,
+grouped by tag or by owner (as in "TODO(owner)"). Examples are:
+
+pathfinder todos -R
+pathfinder todos -R --group-by owner
+pathfinder todos -R --blame-annotations --max-annotation-age 1y
+pathfinder todos -p /path/to/codebase -R --annotation-tags TODO,XXX,BUG
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if groupByFlag != "tag" && groupByFlag != "owner" {
+			return fmt.Errorf("invalid --group-by %q. Options are tag, owner", groupByFlag)
+		}
+
+		projectConfig, configPath, err := loadProjectConfig(configFlag, pathFlag)
+		if err != nil {
+			return err
+		}
+		if configPath != "" {
+			applyProjectConfig(cmd, projectConfig)
+		}
+
+		config, err := scanConfig()
+		if err != nil {
+			return err
+		}
+		// only the annotations are shown, so skip the extra analysis a config file may turn on
+		config.DependencyFlag = false
+		config.GitFlag = false
+		config.ThroughputFlag = false
+		config.GoMetricsFlag = false
+		config.FunctionMetricsFlag = false
+		config.DuplicatesFlag = false
+
+		report, err := pathfinder.ScanContext(cmd.Context(), config)
+		if err != nil {
+			return err
+		}
+		// the flags were valid, so an annotation older than --max-annotation-age doesn't need the usage text
+		cmd.SilenceUsage = true
+
+		ui.PrintAnnotations(report.AnnotationMetrics, groupByFlag)
+		return checkAnnotationAge(report.AnnotationMetrics)
+	},
+}
+
+func init() {
+	// these share their variables with the scan flags, so they must keep the same defaults
+	todosCmd.Flags().StringVarP(&configFlag, "config", "c", "", "Path to a config file. Defaults to .pathfinder.yaml in the scan path if it exists")
+	todosCmd.Flags().StringVarP(&pathFlag, "path", "p", ".", "Path to codebase/repository")
+	todosCmd.Flags().BoolVarP(&recursiveFlag, "recursive", "R", false, "Scan directories recursively")
+	todosCmd.Flags().IntVarP(&maxDepthFlag, "max-depth", "m", -1, "Maximum recursion depth. Only works if --recursive is set")
+	todosCmd.Flags().BoolVarP(&hiddenFlag, "hidden", "i", false, "Include hidden files and directories")
+	todosCmd.Flags().BoolVarP(&noIgnoreFlag, "no-ignore", "", false, "Don't respect .gitignore, .ignore and .git/info/exclude files")
+	todosCmd.Flags().StringArrayVarP(&excludeFlag, "exclude", "e", nil, "Glob of files or directories to skip (e.g. '**/*_generated.go'). Can be repeated")
+	todosCmd.Flags().StringArrayVarP(&includeFlag, "include", "", nil, "Glob of files to scan, skipping everything else (e.g. 'src/**'). Can be repeated")
+	todosCmd.Flags().BoolVarP(&includeVendoredFlag, "include-vendored", "", false, "Scan vendored directories (e.g. vendor, node_modules) and count them as vendored code")
+	todosCmd.Flags().BoolVarP(&includeGeneratedFlag, "include-generated", "", false, "Count generated files, minified code and lockfiles like any other file instead of separately")
+	todosCmd.Flags().StringArrayVarP(&languagesFlag, "languages", "", nil, "JSON or YAML file with custom language definitions. Can be repeated")
+	todosCmd.Flags().StringSliceVarP(&annotationTagsFlag, "annotation-tags", "", nil, "Comment tags counted as annotations (e.g. TODO,FIXME,XXX). Defaults to TODO, FIXME and HACK")
+	todosCmd.Flags().BoolVarP(&blameAnnotationsFlag, "blame-annotations", "", false, "Run git blame on annotations to report their author and age, oldest first")
+	todosCmd.Flags().StringVarP(&maxAnnotationAgeFlag, "max-annotation-age", "", "", "Exit with an error if an annotation is older than this (e.g. 90d, 2y). Implies --blame-annotations")
+
+	todosCmd.Flags().StringVarP(&groupByFlag, "group-by", "", "tag", "Group annotations by tag or owner. Options are tag, owner")
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/andrearcaina/pathfinder/pkg/pathfinder"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PrintAnnotations lists every annotation grouped by tag or by owner ("tag" or "owner"),
+// largest group first.
+func PrintAnnotations(metrics pathfinder.AnnotationMetrics, groupBy string) {
+	if len(metrics.Annotations) == 0 {
+		fmt.Println(TitleStyle().Render("No annotations found."))
+		return
+	}
+
+	groups := make(map[string][]pathfinder.Annotation)
+	for _, annotation := range metrics.Annotations {
+		key := annotation.Tag
+		if groupBy == "owner" {
+			key = annotation.Owner
+			if key == "" {
+				key = "unassigned"
+			}
+		}
+		groups[key] = append(groups[key], annotation)
+	}
+
+	counts := make(map[string]int, len(groups))
+	for key, annotations := range groups {
+		counts[key] = len(annotations)
+	}
+
+	itemStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#B0B0B0")).
+		MarginLeft(4)
+
+	for _, key := range sortedTags(counts) {
+		fmt.Println(SectionStyle().Render(fmt.Sprintf("🔖 %s • %s", key, FormatIntBritishEnglish(counts[key]))))
+		for _, annotation := range groups[key] {
+			// show whichever of tag and owner isn't the group already
+			label := annotation.Tag
+			if groupBy != "owner" {
+				label = annotation.Owner
+			}
+			if label != "" {
+				label = "[" + label + "] "
+			}
+			fmt.Println(itemStyle.Render(fmt.Sprintf("%s:%d %s%s%s", annotation.Path, annotation.Line, label, annotation.Text, blameText(annotation))))
+		}
+	}
+}
+
+// blameText describes who last changed a blamed annotation and how long ago, e.g. " • Ada • 412 days ago".
+func blameText(annotation pathfinder.Annotation) string {
+	if annotation.Commit == "" {
+		return ""
+	}
+	return fmt.Sprintf(" • %s • %s days ago", annotation.Author, FormatIntBritishEnglish(annotation.AgeDays))
+}
+
+// sortedTags orders the keys of counts by count, largest first, then by name.
+func sortedTags(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/andrearcaina/pathfinder/pkg/pathfinder"
@@ -13,6 +14,7 @@ const maxBarWidth = 40
 func PrintReport(report pathfinder.CodebaseReport, throughputMode bool) {
 	if report.CodebaseMetrics.TotalFiles == 0 {
 		fmt.Println("No files analyzed. Please check the path and try again.")
+		printScanErrors(report.Errors)
 		return // exit program
 	}
 
@@ -23,7 +25,15 @@ func PrintReport(report pathfinder.CodebaseReport, throughputMode bool) {
 
 	fmt.Println(TitleStyle().Render("☁️ Pathfinder • Codebase Overview"))
 
-	fmt.Println(strings.Join([]string{
+	if report.Incomplete {
+		fmt.Println(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFD700")).
+			Bold(true).
+			MarginBottom(1).
+			Render("⚠️ Scan stopped early, the numbers below only cover the files counted so far"))
+	}
+
+	badges := []string{
 		BadgeDisplay("🗃️ Files", FormatIntBritishEnglish(report.CodebaseMetrics.TotalFiles)),
 		BadgeDisplay("📂 Directories", FormatIntBritishEnglish(report.CodebaseMetrics.TotalDirs)),
 		BadgeDisplay("🧑‍💻 Languages", FormatIntBritishEnglish(report.CodebaseMetrics.TotalLanguages)),
@@ -31,20 +41,30 @@ func PrintReport(report pathfinder.CodebaseReport, throughputMode bool) {
 		BadgeDisplay("🖥️ Lines of Code", FormatIntBritishEnglish(report.CodebaseMetrics.TotalCode)),
 		BadgeDisplay("💬 Comments", FormatIntBritishEnglish(report.CodebaseMetrics.TotalComments)),
 		BadgeDisplay("🗑️ Blanks", FormatIntBritishEnglish(report.CodebaseMetrics.TotalBlanks)),
-	}, " "))
+	}
+	if report.CodebaseMetrics.TotalMixed > 0 {
+		badges = append(badges, BadgeDisplay("🔀 Mixed", FormatIntBritishEnglish(report.CodebaseMetrics.TotalMixed)))
+	}
+	if report.CodebaseMetrics.TotalDocs > 0 {
+		badges = append(badges, BadgeDisplay("📖 Docstrings", FormatIntBritishEnglish(report.CodebaseMetrics.TotalDocs)))
+	}
+	fmt.Println(strings.Join(badges, " "))
 
 	fmt.Println(SectionStyle().Render("📋 Languages"))
 	for _, lang := range report.LanguageMetrics {
-		fmt.Printf("  %s %.2f%%\n", lang.Metrics.Language, lang.Percentage)
+		fmt.Printf("  %s %.2f%%%s\n", lang.Metrics.Language, lang.Percentage, docCoverageText(lang.Metrics.DocComments, lang.DocCoverage))
 		bar := BarStyle().ViewAs(lang.Percentage / 100.0)
 		fmt.Printf("  %s %d lines\n", bar, lang.Metrics.Lines)
+		for _, child := range lang.Metrics.Children {
+			fmt.Printf("    ↳ %s • %s lines embedded\n", child.Language, FormatIntBritishEnglish(child.Lines))
+		}
 	}
 
 	fmt.Println(SectionStyle().Render("📄 Top Files"))
 	maxLines := 0
 	for i := 0; i < len(report.FileMetrics); i++ {
-		if report.FileMetrics[i].Metrics.Lines > maxLines {
-			maxLines = report.FileMetrics[i].Metrics.Lines
+		if lines := report.FileMetrics[i].Metrics.Total().Lines; lines > maxLines {
+			maxLines = lines
 		}
 	}
 
@@ -53,13 +73,22 @@ func PrintReport(report pathfinder.CodebaseReport, throughputMode bool) {
 	for i := 0; i < len(report.FileMetrics) && i < 10; i++ {
 		f := report.FileMetrics[i]
 
-		ratio := float64(f.Metrics.Lines) / float64(maxLines)
+		lines := f.Metrics.Total().Lines
+		ratio := float64(lines) / float64(maxLines)
 		bar := BarStyle().ViewAs(ratio)
 
-		fmt.Printf("  %s • %s lines\n", f.Path, FormatIntBritishEnglish(f.Metrics.Lines))
+		encoding := ""
+		if f.Encoding != pathfinder.EncodingUTF8 {
+			encoding = " • " + string(f.Encoding)
+		}
+		fmt.Printf("  %s • %s lines%s\n", f.Path, FormatIntBritishEnglish(lines), encoding)
 		fmt.Println("  " + bar)
 	}
 
+	printMostComplexFiles(report.FileMetrics)
+	printLongestFunctions(report.LongestFunctions)
+	printDuplicates(report.DuplicateMetrics)
+
 	// TODO: handle a flag to show all dirs (not recommended for large codebases)
 	// only show top 10 directories
 	fmt.Println(SectionStyle().Render("📂 Directories"))
@@ -72,18 +101,32 @@ func PrintReport(report pathfinder.CodebaseReport, throughputMode bool) {
 			dirName = "root"
 		}
 
-		fmt.Printf("  %s • %.2f%%\n", dirName, d.Percentage)
+		fmt.Printf("  %s • %.2f%%%s\n", dirName, d.Percentage, docCoverageText(d.DocComments, d.DocCoverage))
 		bar := BarStyle().ViewAs(d.Percentage / 100.0)
 		fmt.Println("  " + bar)
 	}
 
+	printFileClasses(report.ClassMetrics)
+	printTestSplit(report.TestMetrics)
+
 	fmt.Println(SectionStyle().Render("🔖 Annotations"))
-	fmt.Printf("  TODO: %s  FIXME: %s  HACK: %s  Total: %s\n",
-		FormatIntBritishEnglish(report.AnnotationMetrics.TotalTODO),
-		FormatIntBritishEnglish(report.AnnotationMetrics.TotalFIXME),
-		FormatIntBritishEnglish(report.AnnotationMetrics.TotalHACK),
-		FormatIntBritishEnglish(report.AnnotationMetrics.TotalAnnotations),
-	)
+	tagCounts := make([]string, 0, len(report.AnnotationMetrics.Tags)+1)
+	for _, tag := range sortedTags(report.AnnotationMetrics.Tags) {
+		tagCounts = append(tagCounts, fmt.Sprintf("%s: %s", tag, FormatIntBritishEnglish(report.AnnotationMetrics.Tags[tag])))
+	}
+	tagCounts = append(tagCounts, "Total: "+FormatIntBritishEnglish(report.AnnotationMetrics.TotalAnnotations))
+	fmt.Println("  " + strings.Join(tagCounts, "  "))
+
+	// blamed annotations are sorted oldest first, so the first few are the ones most worth a look
+	if annotations := report.AnnotationMetrics.Annotations; len(annotations) > 0 && annotations[0].Commit != "" {
+		oldestStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#B0B0B0")).
+			MarginLeft(4)
+		for i := 0; i < len(annotations) && i < 5 && annotations[i].Commit != ""; i++ {
+			a := annotations[i]
+			fmt.Println(oldestStyle.Render(fmt.Sprintf("%s:%d %s %s%s", a.Path, a.Line, a.Tag, a.Text, blameText(a))))
+		}
+	}
 
 	// display dependency metrics if available
 	if len(report.DependencyMetrics.DependencyFiles) > 0 {
@@ -143,6 +186,306 @@ func PrintReport(report pathfinder.CodebaseReport, throughputMode bool) {
 			}
 		}
 	}
+
+	printGeneratedFiles(report.GeneratedMetrics)
+
+	if report.GoMetrics.Files > 0 {
+		printGoMetrics(report.GoMetrics)
+	}
+
+	// display git metrics if available
+	if report.GitMetrics.TotalCommits > 0 {
+		printGitMetrics(report.GitMetrics)
+	}
+
+	printScanErrors(report.Errors)
+}
+
+// printMostComplexFiles shows the 10 files with the highest complexity, next to the largest ones.
+func printMostComplexFiles(files []pathfinder.FileMetricsReport) {
+	complexFiles := make([]pathfinder.FileMetricsReport, 0, len(files))
+	for _, f := range files {
+		if f.Metrics.Total().Complexity > 0 {
+			complexFiles = append(complexFiles, f)
+		}
+	}
+	if len(complexFiles) == 0 {
+		return
+	}
+	sort.SliceStable(complexFiles, func(i, j int) bool {
+		return complexFiles[i].Metrics.Total().Complexity > complexFiles[j].Metrics.Total().Complexity
+	})
+
+	fmt.Println(SectionStyle().Render("🧠 Most Complex Files"))
+	maxComplexity := complexFiles[0].Metrics.Total().Complexity
+	for i := 0; i < len(complexFiles) && i < 10; i++ {
+		f := complexFiles[i]
+		total := f.Metrics.Total()
+
+		fmt.Printf("  %s • complexity %s • nesting %d • longest line %s\n", f.Path,
+			FormatIntBritishEnglish(total.Complexity), total.MaxNesting, FormatIntBritishEnglish(total.LongestLine))
+		bar := BarStyle().ViewAs(float64(total.Complexity) / float64(maxComplexity))
+		fmt.Println("  " + bar)
+	}
+}
+
+// printLongestFunctions shows the 10 longest functions, found with --functions.
+func printLongestFunctions(functions []pathfinder.FunctionMetrics) {
+	if len(functions) == 0 {
+		return
+	}
+
+	fmt.Println(SectionStyle().Render("📏 Longest Functions"))
+	maxLines := functions[0].Lines
+	for i := 0; i < len(functions) && i < 10; i++ {
+		f := functions[i]
+		fmt.Printf("  %s (%s:%d-%d) • %s lines • %d params\n", f.Name, f.Path, f.StartLine, f.EndLine, FormatIntBritishEnglish(f.Lines), f.Params)
+		bar := BarStyle().ViewAs(float64(f.Lines) / float64(maxLines))
+		fmt.Println("  " + bar)
+	}
+}
+
+// printDuplicates shows the share of duplicated code, the 5 largest groups of identical files and the 10
+// longest duplicated blocks, found with --duplicates.
+func printDuplicates(duplicates pathfinder.DuplicateMetrics) {
+	if duplicates.Files == 0 {
+		return
+	}
+
+	fmt.Println(SectionStyle().Render("👯 Duplicated Code"))
+	fmt.Println("  " + strings.Join([]string{
+		BadgeDisplay("📊 Duplicated Lines", FormatIntBritishEnglish(duplicates.DuplicatedLines)),
+		BadgeDisplay("📈 Duplication", fmt.Sprintf("%.2f%%", duplicates.Percentage)),
+		BadgeDisplay("🗃️ Identical Files", FormatIntBritishEnglish(len(duplicates.DuplicateFiles))),
+	}, " "))
+	if duplicates.Partial {
+		fmt.Println("  (the codebase has more code than the duplicate index holds, so the files last in path order were left out)")
+	}
+
+	locationStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#B0B0B0")).
+		MarginLeft(4)
+	for i := 0; i < len(duplicates.DuplicateFiles) && i < 5; i++ {
+		group := duplicates.DuplicateFiles[i]
+		fmt.Printf("  %s identical files • %s lines each\n", FormatIntBritishEnglish(len(group.Paths)), FormatIntBritishEnglish(group.Lines))
+		fmt.Println(locationStyle.Render(strings.Join(group.Paths, ", ")))
+	}
+	for i := 0; i < len(duplicates.Blocks) && i < 10; i++ {
+		block := duplicates.Blocks[i]
+		locations := make([]string, 0, len(block.Locations))
+		for _, l := range block.Locations {
+			locations = append(locations, fmt.Sprintf("%s:%d-%d", l.Path, l.StartLine, l.EndLine))
+		}
+		fmt.Printf("  %s duplicated lines\n", FormatIntBritishEnglish(block.Lines))
+		fmt.Println(locationStyle.Render(strings.Join(locations, " ↔ ")))
+	}
+}
+
+// printFileClasses shows how much of the codebase is first-party code, tests, docs, generated or vendored.
+func printFileClasses(classes []pathfinder.ClassMetricsReport) {
+	fmt.Println(SectionStyle().Render("🏷️ File Classes"))
+	for _, c := range classes {
+		if c.Files == 0 {
+			continue
+		}
+		fmt.Printf("  %s • %.2f%% • %s files • %s lines\n", c.Class, c.Percentage, FormatIntBritishEnglish(c.Files), FormatIntBritishEnglish(c.Lines))
+		bar := BarStyle().ViewAs(c.Percentage / 100.0)
+		fmt.Println("  " + bar)
+	}
+}
+
+// printTestSplit shows the test code per line of production code, overall and for the 5 languages and
+// top-level directories with the most code. The bars are the share of test code.
+func printTestSplit(tests pathfinder.TestMetrics) {
+	if tests.Code+tests.TestCode == 0 {
+		return
+	}
+
+	fmt.Println(SectionStyle().Render("🧪 Tests vs. Code"))
+	fmt.Println("  " + strings.Join([]string{
+		BadgeDisplay("🖥️ Code", FormatIntBritishEnglish(tests.Code)),
+		BadgeDisplay("🧪 Test Code", FormatIntBritishEnglish(tests.TestCode)),
+		BadgeDisplay("⚖️ Ratio", testRatioText(tests.Code, tests.TestCode, tests.Ratio)),
+	}, " "))
+
+	for _, group := range []struct {
+		title  string
+		splits []pathfinder.TestSplitReport
+	}{
+		{"By language", tests.Languages},
+		{"By directory", tests.Directories},
+	} {
+		fmt.Println("  " + group.title)
+		for i := 0; i < len(group.splits) && i < 5; i++ {
+			t := group.splits[i]
+			name := t.Name
+			if name == "." {
+				name = "root"
+			}
+			fmt.Printf("  %s • %s code • %s test • %s\n", name, FormatIntBritishEnglish(t.Code), FormatIntBritishEnglish(t.TestCode), testRatioText(t.Code, t.TestCode, t.Ratio))
+			testShare := 0.0
+			if t.Code+t.TestCode > 0 {
+				testShare = float64(t.TestCode) / float64(t.Code+t.TestCode)
+			}
+			bar := BarStyle().ViewAs(testShare)
+			fmt.Println("  " + bar)
+		}
+	}
+}
+
+// testRatioText shows a ratio of code to test code, which has no value when there is only test code.
+func testRatioText(code, testCode int, ratio float64) string {
+	if code == 0 && testCode > 0 {
+		return "test only"
+	}
+	return fmt.Sprintf("1:%.2f", ratio)
+}
+
+func printGeneratedFiles(generated pathfinder.GeneratedMetrics) {
+	if generated.TotalFiles == 0 {
+		return
+	}
+
+	fmt.Println(SectionStyle().Render("🤖 Generated Files"))
+	fmt.Println("  " + strings.Join([]string{
+		BadgeDisplay("🗃️ Files", FormatIntBritishEnglish(generated.TotalFiles)),
+		BadgeDisplay("📊 Total Lines", FormatIntBritishEnglish(generated.TotalLines)),
+		BadgeDisplay("🖥️ Lines of Code", FormatIntBritishEnglish(generated.TotalCode)),
+	}, " "))
+
+	fileStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#B0B0B0")).
+		MarginLeft(4)
+
+	// only show the first 5 generated files, they aren't part of the totals above
+	for i, f := range generated.Files {
+		if i >= 5 {
+			moreStyle := lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#808080")).
+				Italic(true).
+				MarginLeft(4)
+			fmt.Println(moreStyle.Render(fmt.Sprintf("... and %d more files", len(generated.Files)-5)))
+			break
+		}
+		fmt.Println(fileStyle.Render(fmt.Sprintf("%s • %s lines (%s)", f.Path, FormatIntBritishEnglish(f.Metrics.Total().Lines), f.Generated)))
+	}
+}
+
+func printScanErrors(errors []pathfinder.ScanError) {
+	if len(errors) == 0 {
+		return
+	}
+
+	fmt.Println(SectionStyle().Render("⚠️ Skipped Files"))
+
+	// count errors per kind, keeping the order in which kinds first appear
+	var kinds []pathfinder.ScanErrorKind
+	countByKind := make(map[pathfinder.ScanErrorKind]int)
+	for _, scanErr := range errors {
+		if countByKind[scanErr.Kind] == 0 {
+			kinds = append(kinds, scanErr.Kind)
+		}
+		countByKind[scanErr.Kind]++
+	}
+
+	badges := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		badges = append(badges, BadgeDisplay(string(kind), FormatIntBritishEnglish(countByKind[kind])))
+	}
+	fmt.Println("  " + strings.Join(badges, " "))
+
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#B0B0B0")).
+		MarginLeft(4)
+
+	// only show the first 5 skipped files
+	for i, scanErr := range errors {
+		if i >= 5 {
+			moreStyle := lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#808080")).
+				Italic(true).
+				MarginLeft(4)
+			fmt.Println(moreStyle.Render(fmt.Sprintf("... and %d more files", len(errors)-5)))
+			break
+		}
+		fmt.Println(errorStyle.Render(fmt.Sprintf("%s (%s)", scanErr.Path, scanErr.Kind)))
+	}
+}
+
+func printGoMetrics(goMetrics pathfinder.GoMetrics) {
+	fmt.Println(SectionStyle().Render("🐹 Go"))
+
+	badges := []string{
+		BadgeDisplay("Packages", FormatIntBritishEnglish(goMetrics.Packages)),
+		BadgeDisplay("Exported Funcs", FormatIntBritishEnglish(goMetrics.ExportedFuncs)),
+		BadgeDisplay("Unexported Funcs", FormatIntBritishEnglish(goMetrics.UnexportedFuncs)),
+		BadgeDisplay("Methods", FormatIntBritishEnglish(goMetrics.Methods)),
+		BadgeDisplay("Types", FormatIntBritishEnglish(goMetrics.Types)),
+		BadgeDisplay("Interfaces", FormatIntBritishEnglish(goMetrics.Interfaces)),
+		BadgeDisplay("Tests", FormatIntBritishEnglish(goMetrics.TestFuncs)),
+		BadgeDisplay("Benchmarks", FormatIntBritishEnglish(goMetrics.Benchmarks)),
+	}
+	if goMetrics.ParseErrors > 0 {
+		badges = append(badges, BadgeDisplay("Unparsable Files", FormatIntBritishEnglish(goMetrics.ParseErrors)))
+	}
+	fmt.Println("  " + strings.Join(badges, " "))
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFD700")).
+		Bold(true).
+		MarginLeft(2).
+		MarginTop(1)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#B0B0B0")).
+		MarginLeft(4)
+
+	// functions are sorted most complex first, only show the top 5
+	fmt.Println(headerStyle.Render("Most Complex Functions"))
+	for i := 0; i < len(goMetrics.Functions) && i < 5; i++ {
+		f := goMetrics.Functions[i]
+		fmt.Println(itemStyle.Render(fmt.Sprintf("%s (%s:%d) • complexity %d • %s lines", f.Name, f.Path, f.Line, f.Complexity, FormatIntBritishEnglish(f.Lines))))
+	}
+}
+
+func printGitMetrics(git pathfinder.GitMetrics) {
+	fmt.Println(SectionStyle().Render("🌱 Git History"))
+
+	fmt.Println("  " + strings.Join([]string{
+		BadgeDisplay("Commits", FormatIntBritishEnglish(git.TotalCommits)),
+		BadgeDisplay("Contributors", FormatIntBritishEnglish(git.TotalContributors)),
+		BadgeDisplay("First Commit", git.FirstCommit.Format("2006-01-02")),
+		BadgeDisplay("Last Commit", git.LastCommit.Format("2006-01-02")),
+	}, " "))
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFD700")).
+		Bold(true).
+		MarginLeft(2).
+		MarginTop(1)
+	itemStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#B0B0B0")).
+		MarginLeft(4)
+
+	// only show top 5 contributors
+	fmt.Println(headerStyle.Render("Top Contributors"))
+	for i := 0; i < len(git.Contributors) && i < 5; i++ {
+		c := git.Contributors[i]
+		fmt.Println(itemStyle.Render(fmt.Sprintf("%s • %s commits", c.Name, FormatIntBritishEnglish(c.Commits))))
+	}
+
+	if len(git.Hotspots) == 0 {
+		return
+	}
+
+	fmt.Println(headerStyle.Render("Churn Hotspots"))
+	maxCommits := git.Hotspots[0].Commits
+	for _, f := range git.Hotspots {
+		ratio := float64(f.Commits) / float64(maxCommits)
+		bar := BarStyle().ViewAs(ratio)
+
+		fmt.Printf("  %s • %s commits • %s lines\n", f.Path, FormatIntBritishEnglish(f.Commits), FormatIntBritishEnglish(f.Metrics.Total().Lines))
+		fmt.Println("  " + bar)
+	}
 }
 
 func renderThroughputReport(report pathfinder.CodebaseReport) string {
@@ -241,3 +584,11 @@ func renderMetricPanel(title string, metrics [][2]string) string {
 		Padding(0, 1).
 		Render(strings.Join(lines, "\n"))
 }
+
+// docCoverageText is appended to a language or directory line, and left out when nothing is documented.
+func docCoverageText(docComments int, coverage float64) string {
+	if docComments == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" • %.1f%% documented", coverage)
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/andrearcaina/pathfinder/pkg/pathfinder"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+)
+
+// ScanProgress renders a live spinner with running totals on stderr while a scan is in progress.
+type ScanProgress struct {
+	mu       sync.Mutex
+	latest   pathfinder.ProgressEvent
+	program  *tea.Program
+	finished chan struct{}
+}
+
+// progressModel only owns the spinner, the counts are read from ScanProgress on every render
+// so the scan never blocks on the terminal.
+type progressModel struct {
+	spinner  spinner.Model
+	progress *ScanProgress
+	quitting bool
+}
+
+type stopProgressMsg struct{}
+
+// ProgressSupported reports whether stderr is a terminal that can render a live progress line.
+func ProgressSupported() bool {
+	fd := os.Stderr.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// StartProgress starts rendering the progress line. Pass Update as Config.OnProgress and call Stop once the scan returns.
+func StartProgress() *ScanProgress {
+	s := spinner.New(
+		spinner.WithSpinner(spinner.Dot),
+		spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("#50C878"))),
+	)
+
+	p := &ScanProgress{finished: make(chan struct{})}
+	p.program = tea.NewProgram(
+		progressModel{spinner: s, progress: p},
+		tea.WithOutput(os.Stderr),
+		tea.WithInput(nil),
+		tea.WithoutSignalHandler(), // leave Ctrl-C to the scan command so it can cancel the scan
+	)
+
+	go func() {
+		defer close(p.finished)
+		_, _ = p.program.Run()
+	}()
+
+	return p
+}
+
+// Update records the latest progress event. It is safe to use as Config.OnProgress.
+func (p *ScanProgress) Update(event pathfinder.ProgressEvent) {
+	p.mu.Lock()
+	p.latest = event
+	p.mu.Unlock()
+}
+
+// Stop clears the progress line and waits for the renderer to exit.
+func (p *ScanProgress) Stop() {
+	p.program.Send(stopProgressMsg{})
+	<-p.finished
+}
+
+func (p *ScanProgress) snapshot() pathfinder.ProgressEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.latest
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case stopProgressMsg:
+		m.quitting = true
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	if m.quitting {
+		return "" // leave a clean terminal for the report
+	}
+
+	event := m.progress.snapshot()
+	counts := fmt.Sprintf("Scanning • %s files • %s dirs • %s lines",
+		FormatIntBritishEnglish(event.FilesCounted),
+		FormatIntBritishEnglish(event.DirsEntered),
+		FormatIntBritishEnglish(event.LinesCounted),
+	)
+	if event.Errors > 0 {
+		counts += fmt.Sprintf(" • %s skipped", FormatIntBritishEnglish(event.Errors))
+	}
+
+	pathStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#808080")).
+		Italic(true).
+		MaxWidth(60)
+
+	return fmt.Sprintf("%s %s %s", m.spinner.View(), counts, pathStyle.Render(event.Path))
+}
@@ -0,0 +1,125 @@
+package pathfinder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// defaultAnnotationTags are counted when Config.AnnotationTagsFlag is empty.
+var defaultAnnotationTags = []string{"TODO", "FIXME", "HACK"}
+
+// annotationMatcher finds annotation tags in comment text.
+type annotationMatcher struct {
+	tags [][]byte
+}
+
+func newAnnotationMatcher(tags []string) *annotationMatcher {
+	if len(tags) == 0 {
+		tags = defaultAnnotationTags
+	}
+	m := &annotationMatcher{tags: make([][]byte, len(tags))}
+	for i, tag := range tags {
+		m.tags[i] = []byte(tag)
+	}
+	return m
+}
+
+// validateAnnotationTags checks that tags are words, since they are matched on word boundaries.
+func validateAnnotationTags(tags []string) error {
+	for _, tag := range tags {
+		if tag == "" {
+			return errors.New("annotation tags can't be empty")
+		}
+		for i := 0; i < len(tag); i++ {
+			if !isWordByte(tag[i]) {
+				return fmt.Errorf("invalid annotation tag %q. Tags can only contain letters, digits and underscores", tag)
+			}
+		}
+	}
+	return nil
+}
+
+type tagMatch struct {
+	tag        []byte
+	start, end int
+}
+
+// match adds every tag in the comment text of a line to ann. Each annotation's text runs up to the next tag.
+func (m *annotationMatcher) match(comment []byte, line int, ann *AnnotationMetrics) {
+	var matches []tagMatch
+	for _, tag := range m.tags {
+		for offset := 0; ; {
+			i := bytes.Index(comment[offset:], tag)
+			if i == -1 {
+				break
+			}
+			start, end := offset+i, offset+i+len(tag)
+			offset = end
+			if (start > 0 && isWordByte(comment[start-1])) || (end < len(comment) && isWordByte(comment[end])) {
+				continue // part of a longer word, e.g. "TODOList" or "MYTODO"
+			}
+			matches = append(matches, tagMatch{tag: tag, start: start, end: end})
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	for i, match := range matches {
+		rest := comment[match.end:]
+		if i+1 < len(matches) {
+			rest = comment[match.end:matches[i+1].start]
+		}
+
+		annotation := Annotation{Line: line, Tag: string(match.tag)}
+		if bytes.HasPrefix(rest, []byte("(")) {
+			if owner, after, ok := bytes.Cut(rest[1:], []byte(")")); ok {
+				annotation.Owner = string(bytes.TrimSpace(owner))
+				rest = after
+			}
+		}
+		annotation.Text = string(bytes.TrimSpace(bytes.TrimLeft(bytes.TrimSpace(rest), ":-")))
+		ann.addAnnotation(annotation)
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 0x80 || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+func (a *AnnotationMetrics) addAnnotation(annotation Annotation) {
+	switch annotation.Tag {
+	case "TODO":
+		a.TotalTODO++
+	case "FIXME":
+		a.TotalFIXME++
+	case "HACK":
+		a.TotalHACK++
+	}
+	a.TotalAnnotations++
+	if a.Tags == nil {
+		a.Tags = map[string]int{}
+	}
+	a.Tags[annotation.Tag]++
+	a.Annotations = append(a.Annotations, annotation)
+}
+
+// add sums other into a and appends its annotations.
+func (a *AnnotationMetrics) add(other AnnotationMetrics) {
+	a.TotalTODO += other.TotalTODO
+	a.TotalFIXME += other.TotalFIXME
+	a.TotalHACK += other.TotalHACK
+	a.TotalAnnotations += other.TotalAnnotations
+	for tag, count := range other.Tags {
+		if a.Tags == nil {
+			a.Tags = map[string]int{}
+		}
+		a.Tags[tag] += count
+	}
+	a.Annotations = append(a.Annotations, other.Annotations...)
+}
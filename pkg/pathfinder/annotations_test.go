@@ -0,0 +1,68 @@
+package pathfinder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		tags     []string
+		content  string
+		want     []Annotation
+	}{
+		{
+			name:     "owner and text",
+			language: "Go",
+			content:  "x := 1\n// TODO(alice): retry on timeout\n",
+			want:     []Annotation{{Line: 2, Tag: "TODO", Owner: "alice", Text: "retry on timeout"}},
+		},
+		{
+			name:     "word boundaries",
+			language: "Go",
+			content:  "// update the TODOList and MYFIXME\n// @HACK - works for now\n",
+			want:     []Annotation{{Line: 2, Tag: "HACK", Text: "works for now"}},
+		},
+		{
+			name:     "several tags on a line",
+			language: "Python",
+			content:  "# TODO: split this FIXME: and fix that\n",
+			want: []Annotation{
+				{Line: 1, Tag: "TODO", Text: "split this"},
+				{Line: 1, Tag: "FIXME", Text: "and fix that"},
+			},
+		},
+		{
+			name:     "custom tags in a block comment",
+			language: "C",
+			tags:     []string{"XXX", "NOTE"},
+			content:  "/* XXX: racy */ int x;\n/*\n * NOTE(bob) keep in sync\n * TODO: not a configured tag\n */\n",
+			want: []Annotation{
+				{Line: 1, Tag: "XXX", Text: "racy"},
+				{Line: 3, Tag: "NOTE", Owner: "bob", Text: "keep in sync"},
+			},
+		},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			langDef := languages.determineLangByName(tt.language)
+			config := Config{BufferSizeFlag: 4096, AnnotationTagsFlag: tt.tags}
+			count, err := countLinesInFile(strings.NewReader(tt.content), config, languages, langDef)
+			if err != nil {
+				t.Fatalf("countLinesInFile() error = %v", err)
+			}
+			got := count.annotations
+			if !reflect.DeepEqual(got.Annotations, tt.want) {
+				t.Fatalf("Annotations = %+v, want %+v", got.Annotations, tt.want)
+			}
+			if got.TotalAnnotations != len(tt.want) {
+				t.Fatalf("TotalAnnotations = %d, want %d", got.TotalAnnotations, len(tt.want))
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package pathfinder
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
 )
 
@@ -13,6 +15,12 @@ func Version() string {
 // Scan is the main entry point for the library.
 // It takes a Config and returns a detailed CodebaseReport.
 func Scan(config Config) (CodebaseReport, error) {
+	return ScanContext(context.Background(), config)
+}
+
+// ScanContext is like Scan but stops early when ctx is canceled or its deadline passes.
+// In that case it returns the partial report aggregated so far, with Incomplete set, alongside ctx.Err().
+func ScanContext(ctx context.Context, config Config) (CodebaseReport, error) {
 	// set defaults if zero-values are present
 	if config.PathFlag == "" {
 		config.PathFlag = "."
@@ -27,11 +35,53 @@ func Scan(config Config) (CodebaseReport, error) {
 		config.WorkerFlag = 16 // default to 16 concurrent workers
 	}
 
+	if len(config.TestPatternsFlag) == 0 {
+		config.TestPatternsFlag = defaultTestPatterns
+	}
+
+	if config.MinDuplicateLinesFlag == 0 {
+		config.MinDuplicateLinesFlag = defaultMinDuplicateLines
+	}
+
+	if config.MixedLinesFlag == "" {
+		config.MixedLinesFlag = MixedAsCode
+	}
+
 	// validation
 	if !config.RecursiveFlag && config.MaxDepthFlag != -1 {
 		return CodebaseReport{}, errors.New("--max-depth flag is ignored when --recursive is false")
 	}
 
+	switch config.MixedLinesFlag {
+	case MixedAsCode, MixedAsComment, MixedAsBoth:
+		// valid so do nothing
+	default:
+		return CodebaseReport{}, fmt.Errorf("invalid mixed lines mode %q. Allowed values are code, comment, both", config.MixedLinesFlag)
+	}
+
+	if config.MinDuplicateLinesFlag < 2 {
+		return CodebaseReport{}, fmt.Errorf("invalid minimum duplicate lines %d. It must be at least 2", config.MinDuplicateLinesFlag)
+	}
+
+	if err := validateAnnotationTags(config.AnnotationTagsFlag); err != nil {
+		return CodebaseReport{}, err
+	}
+
+	if err := validateGlobs("--exclude", config.ExcludeFlag); err != nil {
+		return CodebaseReport{}, err
+	}
+	if err := validateGlobs("--include", config.IncludeFlag); err != nil {
+		return CodebaseReport{}, err
+	}
+	if err := validateGlobs("--test-pattern", config.TestPatternsFlag); err != nil {
+		return CodebaseReport{}, err
+	}
+
+	languages, err := scanLanguages(config.Languages)
+	if err != nil {
+		return CodebaseReport{}, err
+	}
+
 	absPath, err := filepath.Abs(config.PathFlag)
 	if err != nil {
 		return CodebaseReport{}, err
@@ -48,8 +98,9 @@ func Scan(config Config) (CodebaseReport, error) {
 	// prepare internal config (safe modification since we passed by value)
 	config.PathFlag = absPath
 	config.BufferSizeFlag = config.BufferSizeFlag * 1024
+	config.TestPatternsFlag = normalizeGlobs(config.TestPatternsFlag)
 
-	return scanCodebase(config)
+	return scanCodebase(ctx, config, languages)
 }
 
 // ScannedLanguages returns a list of all programming languages found in the scanned codebase.
@@ -85,11 +136,13 @@ func (c CodebaseReport) ScannedFiles() []string {
 	return files
 }
 
-// SupportedLanguages returns a list of all supported programming languages that Pathfinder can analyze.
+// SupportedLanguages returns a list of all supported programming languages that Pathfinder can analyze,
+// including the ones added with RegisterLanguage.
 func SupportedLanguages() []string {
-	languages := make([]string, 0, len(languageDefinitions))
+	registry, _ := scanLanguages(nil) // registered languages were already validated by RegisterLanguage
 
-	for _, langDef := range languageDefinitions {
+	languages := make([]string, 0, len(registry.definitions))
+	for _, langDef := range registry.definitions {
 		languages = append(languages, langDef.Name)
 	}
 	return languages
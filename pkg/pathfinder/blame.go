@@ -0,0 +1,157 @@
+package pathfinder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uncommittedHash is what git blame reports for lines that aren't committed yet.
+const uncommittedHash = "0000000000000000000000000000000000000000"
+
+// blameLine is the last commit that changed a line.
+type blameLine struct {
+	commit string
+	author string
+	date   time.Time
+}
+
+// blameAnnotations fills in the author, commit and age of each annotation with one `git blame` per file.
+// Files git doesn't track are left as they are, as are the paths in skip, whose line numbers aren't
+// physical lines (like notebook cells).
+func blameAnnotations(ctx context.Context, flags Config, annotations []Annotation, skip map[string]bool, now time.Time) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("blaming annotations requires a local git installation")
+	}
+	if _, err := runGit(ctx, flags.PathFlag, "rev-parse", "--show-toplevel"); err != nil {
+		return fmt.Errorf("%s is not inside a git repository, so annotations can't be blamed", flags.PathFlag)
+	}
+
+	byPath := map[string][]int{}
+	for i, annotation := range annotations {
+		if !skip[annotation.Path] {
+			byPath[annotation.Path] = append(byPath[annotation.Path], i)
+		}
+	}
+
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for range min(flags.WorkerFlag, len(byPath)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				// each file's annotations are only touched by the goroutine blaming it
+				blameFile(ctx, flags.PathFlag, path, annotations, byPath[path], now)
+			}
+		}()
+	}
+	for path := range byPath {
+		paths <- path
+	}
+	close(paths)
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// sortByAge orders annotations oldest first, with the ones that couldn't be blamed last.
+func sortByAge(annotations []Annotation) {
+	sort.SliceStable(annotations, func(i, j int) bool {
+		a, b := annotations[i], annotations[j]
+		if a.Date.IsZero() != b.Date.IsZero() {
+			return !a.Date.IsZero()
+		}
+		return a.Date.Before(b.Date)
+	})
+}
+
+func blameFile(ctx context.Context, rootPath, path string, annotations []Annotation, indices []int, now time.Time) {
+	args := []string{"blame", "--line-porcelain"}
+	lines := map[int]bool{}
+	for _, i := range indices {
+		if line := annotations[i].Line; !lines[line] {
+			lines[line] = true
+			args = append(args, "-L", fmt.Sprintf("%d,%d", line, line))
+		}
+	}
+	args = append(args, "--", path)
+
+	out, err := runGit(ctx, rootPath, args...)
+	if err != nil {
+		return // untracked or unreadable by git, which isn't worth failing the scan over
+	}
+	blamed, err := parseGitBlame(out)
+	if err != nil {
+		return
+	}
+
+	for _, i := range indices {
+		line, ok := blamed[annotations[i].Line]
+		if !ok || line.commit == uncommittedHash {
+			continue
+		}
+		annotations[i].Commit = line.commit
+		annotations[i].Author = line.author
+		annotations[i].Date = line.date
+		annotations[i].AgeDays = int(now.Sub(line.date).Hours() / 24)
+	}
+}
+
+// parseGitBlame reads `git blame --line-porcelain` output into the blame of each final line number.
+func parseGitBlame(out []byte) (map[int]blameLine, error) {
+	blamed := map[int]blameLine{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var current blameLine
+	finalLine := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// the line's content ends each entry
+			blamed[finalLine] = current
+			current = blameLine{}
+		case current.commit == "":
+			// header: "<hash> <original line> <final line> [<lines in group>]"
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected git blame output: %q", line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected git blame output: %q", line)
+			}
+			current.commit, finalLine = fields[0], n
+		case strings.HasPrefix(line, "author "):
+			current.author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			unix, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid blame timestamp %q: %w", line, err)
+			}
+			current.date = time.Unix(unix, 0).UTC()
+		}
+	}
+	return blamed, scanner.Err()
+}
+
+// OlderThan returns the blamed annotations whose last change is older than age.
+func (a AnnotationMetrics) OlderThan(age time.Duration) []Annotation {
+	var old []Annotation
+	for _, annotation := range a.Annotations {
+		if !annotation.Date.IsZero() && time.Duration(annotation.AgeDays)*24*time.Hour > age {
+			old = append(old, annotation)
+		}
+	}
+	return old
+}
@@ -0,0 +1,194 @@
+package pathfinder
+
+import (
+	"sort"
+	"strings"
+)
+
+// fileClasses is the order classes are reported in.
+var fileClasses = []FileClass{FileClassFirstParty, FileClassTest, FileClassDocs, FileClassGenerated, FileClassVendored}
+
+var (
+	// vendoredDirs hold third-party code checked into the repository. vendor and node_modules are also
+	// default excludes, so they are only walked with IncludeVendoredFlag or NoDefaultExcludesFlag.
+	vendoredDirs = map[string]struct{}{
+		"vendor":           {},
+		"node_modules":     {},
+		"third_party":      {},
+		"third-party":      {},
+		"thirdparty":       {},
+		"bower_components": {},
+		"jspm_packages":    {},
+		"Pods":             {},
+		"Carthage":         {},
+	}
+
+	// defaultTestPatterns are doublestar globs of test files and test fixtures, relative to the scan root,
+	// used when Config.TestPatternsFlag is empty.
+	// File name conventions only apply to the source extensions that use them, so data like maven_test.json isn't a test.
+	defaultTestPatterns = []string{
+		"**/*_test.{go,py,rb,exs,dart,c,cc,cpp,js,ts}",
+		"**/*_spec.{rb,lua,js,ts}",
+		"**/*.{test,spec}.{js,jsx,ts,tsx,mjs,cjs,mts,cts}",
+		"**/test_*.py",
+		"**/*{Test,Tests}.{java,kt,scala,groovy,cs,swift,php,m}",
+		"**/test/**", "**/tests/**", "**/__tests__/**", "**/spec/**", "**/testdata/**",
+	}
+
+	// docsPatterns are doublestar globs of documentation directories, relative to the scan root.
+	docsPatterns = []string{"**/doc/**", "**/docs/**", "**/documentation/**"}
+
+	// docLanguages are prose rather than code, wherever they live.
+	docLanguages = map[string]struct{}{
+		"Markdown":         {},
+		"reStructuredText": {},
+		"AsciiDoc":         {},
+		"Org":              {},
+		"LaTeX":            {},
+	}
+)
+
+func isVendoredDir(name string) bool {
+	_, ok := vendoredDirs[name]
+	return ok
+}
+
+// classifyFile works out the class of a counted file from its slash path relative to the scan root,
+// its language, why it looks generated (see generatedReason) and the globs of test files. The first match
+// wins in the order vendored, generated, test, documentation, so a generated file in vendor/ is vendored.
+func classifyFile(rel, language, generated string, testPatterns []string) FileClass {
+	dirs := strings.Split(rel, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if isVendoredDir(dir) {
+			return FileClassVendored
+		}
+	}
+	if generated != "" {
+		return FileClassGenerated
+	}
+	if isTestFile(testPatterns, rel) {
+		return FileClassTest
+	}
+	if _, ok := docLanguages[language]; ok || matchAnyGlob(docsPatterns, rel) {
+		return FileClassDocs
+	}
+	return FileClassFirstParty
+}
+
+// isTestFile reports whether a file or one of its directories matches testPatterns, so a directory
+// pattern like "e2e/" covers everything inside it, as it would for ExcludeFlag.
+func isTestFile(testPatterns []string, rel string) bool {
+	if matchAnyGlob(testPatterns, rel) {
+		return true
+	}
+	dirs := strings.Split(rel, "/")
+	for i := 1; i < len(dirs); i++ {
+		if matchAnyGlob(testPatterns, strings.Join(dirs[:i], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildClassStats reports every class in fileClasses order, including the empty ones.
+func buildClassStats(statsMap map[FileClass]*LanguageMetrics) []ClassMetricsReport {
+	totalLines := 0
+	for _, metrics := range statsMap {
+		totalLines += metrics.Lines
+	}
+
+	stats := make([]ClassMetricsReport, 0, len(fileClasses))
+	for _, class := range fileClasses {
+		report := ClassMetricsReport{Class: class}
+		if metrics := statsMap[class]; metrics != nil {
+			report.Files = metrics.Files
+			report.Lines = metrics.Lines
+			report.Code = metrics.Code
+			if totalLines > 0 {
+				report.Percentage = float64(metrics.Lines) / float64(totalLines) * 100
+			}
+		}
+		stats = append(stats, report)
+	}
+	return stats
+}
+
+// testSplit accumulates the code of first-party and test files per language and top-level directory.
+// Documentation, generated and vendored files are neither.
+type testSplit struct {
+	total       TestSplitReport
+	languages   map[string]*TestSplitReport
+	directories map[string]*TestSplitReport
+}
+
+func newTestSplit() *testSplit {
+	return &testSplit{
+		languages:   map[string]*TestSplitReport{},
+		directories: map[string]*TestSplitReport{},
+	}
+}
+
+// add counts a file of the given class. Embedded languages count towards their own language.
+func (s *testSplit) add(class FileClass, relPath string, metrics LanguageMetrics) {
+	if class != FileClassFirstParty && class != FileClassTest {
+		return
+	}
+	test := class == FileClassTest
+
+	s.total.add(test, metrics.Total())
+	s.entry(s.directories, topLevelDir(relPath)).add(test, metrics.Total())
+	s.entry(s.languages, metrics.Language).add(test, metrics)
+	for _, child := range metrics.Children {
+		s.entry(s.languages, child.Language).add(test, child)
+	}
+}
+
+func (s *testSplit) entry(entries map[string]*TestSplitReport, name string) *TestSplitReport {
+	entry := entries[name]
+	if entry == nil {
+		entry = &TestSplitReport{Name: name}
+		entries[name] = entry
+	}
+	return entry
+}
+
+func (r *TestSplitReport) add(test bool, metrics LanguageMetrics) {
+	if test {
+		r.TestFiles += metrics.Files
+		r.TestCode += metrics.Code
+	} else {
+		r.Files += metrics.Files
+		r.Code += metrics.Code
+	}
+	r.Ratio = 0
+	if r.Code > 0 {
+		r.Ratio = float64(r.TestCode) / float64(r.Code)
+	}
+}
+
+// build reports the languages and directories with the most code (production and test) first.
+func (s *testSplit) build() TestMetrics {
+	sorted := func(entries map[string]*TestSplitReport) []TestSplitReport {
+		reports := make([]TestSplitReport, 0, len(entries))
+		for _, entry := range entries {
+			reports = append(reports, *entry)
+		}
+		sort.Slice(reports, func(i, j int) bool {
+			if a, b := reports[i].Code+reports[i].TestCode, reports[j].Code+reports[j].TestCode; a != b {
+				return a > b
+			}
+			return reports[i].Name < reports[j].Name
+		})
+		return reports
+	}
+
+	return TestMetrics{
+		Files:       s.total.Files,
+		TestFiles:   s.total.TestFiles,
+		Code:        s.total.Code,
+		TestCode:    s.total.TestCode,
+		Ratio:       s.total.Ratio,
+		Languages:   sorted(s.languages),
+		Directories: sorted(s.directories),
+	}
+}
@@ -0,0 +1,140 @@
+package pathfinder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyFile(t *testing.T) {
+	tests := []struct {
+		rel       string
+		language  string
+		generated string
+		want      FileClass
+	}{
+		{rel: "main.go", language: "Go", want: FileClassFirstParty},
+		{rel: "vendor/github.com/pkg/errors/errors.go", language: "Go", want: FileClassVendored},
+		{rel: "web/node_modules/react/index.test.js", language: "JavaScript", want: FileClassVendored},
+		{rel: "api/api.pb.go", language: "Go", generated: "marker", want: FileClassGenerated},
+		{rel: "scanner_test.go", language: "Go", want: FileClassTest},
+		{rel: "src/app.spec.ts", language: "TypeScript", want: FileClassTest},
+		{rel: "tests/conftest.py", language: "Python", want: FileClassTest},
+		{rel: "src/test/java/LoaderTest.java", language: "Java", want: FileClassTest},
+		{rel: "Core/LoaderTests.cs", language: "C#", want: FileClassTest},
+		{rel: "examples/maven_test.json", language: "JSON", want: FileClassFirstParty},
+		{rel: "README.md", language: "Markdown", want: FileClassDocs},
+		{rel: "docs/conf.py", language: "Python", want: FileClassDocs},
+		{rel: "vendor", language: "Go", want: FileClassFirstParty},
+		{rel: "src/latest.go", language: "Go", want: FileClassFirstParty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			if got := classifyFile(tt.rel, tt.language, tt.generated, defaultTestPatterns); got != tt.want {
+				t.Fatalf("classifyFile(%q) = %q, want %q", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanClassTotals(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"main.go":             "package main\n\nfunc main() {}\n",
+		"main_test.go":        "package main\n",
+		"README.md":           "# Title\n",
+		"gen.go":              "// Code generated by gen. DO NOT EDIT.\npackage main\n",
+		"vendor/dep/dep.go":   "package dep\n\nvar X = 1\n",
+		"node_modules/m/i.js": "x();\n",
+	})
+
+	tests := []struct {
+		name   string
+		config Config
+		want   map[FileClass]int // lines per class
+	}{
+		{
+			name:   "vendored skipped",
+			config: Config{PathFlag: root, RecursiveFlag: true, MaxDepthFlag: -1},
+			want:   map[FileClass]int{FileClassFirstParty: 3, FileClassTest: 1, FileClassDocs: 1, FileClassGenerated: 2, FileClassVendored: 0},
+		},
+		{
+			name:   "vendored included",
+			config: Config{PathFlag: root, RecursiveFlag: true, MaxDepthFlag: -1, IncludeVendoredFlag: true},
+			want:   map[FileClass]int{FileClassFirstParty: 3, FileClassTest: 1, FileClassDocs: 1, FileClassGenerated: 2, FileClassVendored: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Scan(tt.config)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if len(report.ClassMetrics) != len(fileClasses) {
+				t.Fatalf("ClassMetrics = %+v, want every class", report.ClassMetrics)
+			}
+			for _, class := range report.ClassMetrics {
+				if class.Lines != tt.want[class.Class] {
+					t.Fatalf("%s lines = %d, want %d", class.Class, class.Lines, tt.want[class.Class])
+				}
+			}
+		})
+	}
+}
+
+func TestScanTestSplit(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"api/api.go":          "package api\n\nfunc A() {}\n\nfunc B() {}\n",
+		"api/api_test.go":     "package api\n\nfunc TestA() {}\n",
+		"web/app.js":          "run();\nstop();\n",
+		"web/e2e/login.js":    "login();\n",
+		"web/__tests__/a.js":  "expect(1);\n",
+		"docs/guide.md":       "# Guide\n",
+		"vendor/dep/dep.go":   "package dep\n",
+		"scripts/test_run.py": "print(1)\n",
+	})
+
+	tests := []struct {
+		name        string
+		patterns    []string
+		languages   map[string][2]int // code and test code per language
+		directories map[string][2]int // code and test code per top-level directory
+	}{
+		{
+			name:        "default conventions",
+			languages:   map[string][2]int{"Go": {3, 2}, "JavaScript": {3, 1}, "Python": {0, 1}},
+			directories: map[string][2]int{"api": {3, 2}, "web": {3, 1}, "scripts": {0, 1}},
+		},
+		{
+			name:        "custom patterns",
+			patterns:    []string{"*_test.go", "e2e/"},
+			languages:   map[string][2]int{"Go": {3, 2}, "JavaScript": {3, 1}, "Python": {1, 0}},
+			directories: map[string][2]int{"api": {3, 2}, "web": {3, 1}, "scripts": {1, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Scan(Config{PathFlag: root, RecursiveFlag: true, MaxDepthFlag: -1, TestPatternsFlag: tt.patterns})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			split := func(reports []TestSplitReport) map[string][2]int {
+				got := map[string][2]int{}
+				for _, report := range reports {
+					got[report.Name] = [2]int{report.Code, report.TestCode}
+				}
+				return got
+			}
+			if got := split(report.TestMetrics.Languages); !reflect.DeepEqual(got, tt.languages) {
+				t.Fatalf("Languages = %v, want %v", got, tt.languages)
+			}
+			if got := split(report.TestMetrics.Directories); !reflect.DeepEqual(got, tt.directories) {
+				t.Fatalf("Directories = %v, want %v", got, tt.directories)
+			}
+		})
+	}
+}
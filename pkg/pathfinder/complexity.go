@@ -0,0 +1,94 @@
+package pathfinder
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// tabWidth is how many columns a tab indents by, to compare tab and space indentation.
+const tabWidth = 4
+
+// complexityCounter measures the branches, nesting depth and longest line of one language in a file.
+type complexityCounter struct {
+	keywords  [][]byte // branch tokens made of word characters, matched as whole words (e.g. "if")
+	operators [][]byte // the other branch tokens, matched anywhere in code (e.g. "&&")
+
+	depth     int  // current brace depth
+	maxDepth  int  // deepest brace depth so far
+	sawBraces bool // the file nests with braces, so indentation doesn't matter
+	minIndent int  // smallest indentation of an indented code line in columns, taken as one level
+	maxIndent int  // largest indentation of a code line in columns
+}
+
+func newComplexityCounter(langDef *LanguageDefinition) *complexityCounter {
+	c := &complexityCounter{}
+	for _, token := range langDef.Complexity {
+		if isWordByte(token[0]) && isWordByte(token[len(token)-1]) {
+			c.keywords = append(c.keywords, []byte(token))
+		} else {
+			c.operators = append(c.operators, []byte(token))
+		}
+	}
+	return c
+}
+
+// measure adds a physical line (without its line ending) and the code on it (see lineCounter.code) to metrics.
+func (c *complexityCounter) measure(physical, code []byte, metrics *LanguageMetrics) {
+	metrics.LongestLine = max(metrics.LongestLine, utf8.RuneCount(physical))
+	if len(bytes.TrimSpace(code)) == 0 {
+		return
+	}
+
+	for _, keyword := range c.keywords {
+		for offset := 0; ; {
+			i := bytes.Index(code[offset:], keyword)
+			if i == -1 {
+				break
+			}
+			start, end := offset+i, offset+i+len(keyword)
+			offset = end
+			if (start == 0 || !isWordByte(code[start-1])) && (end == len(code) || !isWordByte(code[end])) {
+				metrics.Complexity++
+			}
+		}
+	}
+	for _, operator := range c.operators {
+		metrics.Complexity += bytes.Count(code, operator)
+	}
+
+	for _, b := range code {
+		switch b {
+		case '{':
+			c.sawBraces = true
+			c.depth++
+			c.maxDepth = max(c.maxDepth, c.depth)
+		case '}':
+			c.depth = max(c.depth-1, 0)
+		}
+	}
+
+	indent := 0
+	for _, b := range physical {
+		if b == ' ' {
+			indent++
+		} else if b == '\t' {
+			indent += tabWidth
+		} else {
+			break
+		}
+	}
+	if indent > 0 && (c.minIndent == 0 || indent < c.minIndent) {
+		c.minIndent = indent
+	}
+	c.maxIndent = max(c.maxIndent, indent)
+}
+
+// finish sets the nesting depth of the file in metrics, once every line is measured.
+func (c *complexityCounter) finish(metrics *LanguageMetrics) {
+	switch {
+	case c.sawBraces:
+		metrics.MaxNesting = c.maxDepth
+	case c.minIndent > 0:
+		metrics.MaxNesting = c.maxIndent / c.minIndent
+	}
+}
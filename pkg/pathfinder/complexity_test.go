@@ -0,0 +1,72 @@
+package pathfinder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name        string
+		language    string
+		content     string
+		complexity  int
+		maxNesting  int
+		longestLine int
+	}{
+		{
+			name:        "branches and braces",
+			language:    "Go",
+			content:     "func f(a, b bool) {\n\tif a && b {\n\t\tfor {\n\t\t}\n\t}\n}\n",
+			complexity:  3,
+			maxNesting:  3,
+			longestLine: 19,
+		},
+		{
+			name:       "strings and comments ignored",
+			language:   "Go",
+			content:    "s := \"if { for\" // if && {\n/* case || */\nx := notif\n",
+			complexity: 0,
+			maxNesting: 0,
+		},
+		{
+			name:       "indentation without braces",
+			language:   "Python",
+			content:    "def f(x):\n    if x and y:\n        while x:\n            x -= 1\n    # if deeply\n",
+			complexity: 3,
+			maxNesting: 3,
+		},
+		{
+			name:        "longest line in characters",
+			language:    "Go",
+			content:     "// héllo\r\nx := 1\r\n",
+			complexity:  0,
+			longestLine: 8,
+		},
+		{
+			name:       "embedded languages measured separately",
+			language:   "HTML",
+			content:    "<div>\n<script>\nif (a || b) { run(); }\n</script>\n</div>\n",
+			complexity: 2,
+			maxNesting: 1,
+		},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			langDef := languages.determineLangByName(tt.language)
+			count, err := countLinesInFile(strings.NewReader(tt.content), Config{BufferSizeFlag: 4096}, languages, langDef)
+			if err != nil {
+				t.Fatalf("countLinesInFile() error = %v", err)
+			}
+			total := count.metrics.Total()
+			if total.Complexity != tt.complexity || total.MaxNesting != tt.maxNesting {
+				t.Fatalf("Complexity = %d, MaxNesting = %d, want %d and %d", total.Complexity, total.MaxNesting, tt.complexity, tt.maxNesting)
+			}
+			if tt.longestLine != 0 && total.LongestLine != tt.longestLine {
+				t.Fatalf("LongestLine = %d, want %d", total.LongestLine, tt.longestLine)
+			}
+		})
+	}
+}
@@ -5,75 +5,121 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
 )
 
-func fileCounter(path string, bufferSize int, langDef *LanguageDefinition) (LanguageMetrics, AnnotationMetrics, error) {
+// fileCount is what counting a single file produces.
+type fileCount struct {
+	metrics     LanguageMetrics
+	annotations AnnotationMetrics
+	encoding    Encoding
+	generated   string                 // why the file looks generated, "" if it doesn't
+	functions   []FunctionMetrics      // only found if FunctionMetricsFlag is set
+	duplicates  *duplicateFingerprints // only set if DuplicatesFlag is set
+}
+
+// fileCounter counts the file at path in langDef. Files whose content turns out to be binary return errBinaryContent.
+func fileCounter(path string, flags Config, languages *languageRegistry, langDef *LanguageDefinition) (fileCount, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return LanguageMetrics{}, AnnotationMetrics{}, err
+		return fileCount{}, err
 	}
 	defer f.Close()
 
-	langMetrics, annMetrics, err := countLinesInFile(f, bufferSize, langDef)
+	br := bufio.NewReaderSize(f, flags.BufferSizeFlag)
+	head, err := peekHead(br)
+	if err != nil {
+		return fileCount{}, err
+	}
+	if looksBinary(head) {
+		return fileCount{}, errBinaryContent
+	}
+	generated := generatedReason(filepath.Base(path), decodeHead(head))
+
+	r, enc, err := decodeReader(br, head)
+	if err != nil {
+		return fileCount{}, err
+	}
+
+	count, err := countLinesInFile(r, flags, languages, langDef)
 	if err != nil {
-		return LanguageMetrics{}, AnnotationMetrics{}, err
+		return fileCount{}, err
 	}
 
-	return langMetrics, annMetrics, nil
+	count.encoding = enc
+	count.generated = generated
+	return count, nil
 }
 
-func countLinesInFile(r io.Reader, bufferSize int, langDef *LanguageDefinition) (LanguageMetrics, AnnotationMetrics, error) {
-	br := bufio.NewReaderSize(r, bufferSize)
-	inBlockComment := false
+// countLinesInFile counts the lines of r in langDef. Regions in embedded languages (e.g. <script> blocks
+// or fenced code) are counted with their own language and reported in LanguageMetrics.Children.
+func countLinesInFile(r io.Reader, flags Config, languages *languageRegistry, langDef *LanguageDefinition) (fileCount, error) {
+	if langDef.Embedded == EmbedNotebook {
+		return countNotebook(r, flags, languages, langDef)
+	}
+
+	br := bufio.NewReaderSize(r, flags.BufferSizeFlag)
+	counter := newLineCounter(langDef)
+	splitter := newRegionSplitter(langDef, languages)
 
 	var langMetrics LanguageMetrics
 	var annMetrics AnnotationMetrics
+	var children map[string]*LanguageMetrics
+	var childCounters map[string]*lineCounter
+	tags := newAnnotationMatcher(flags.AnnotationTagsFlag)
+	var duplicates *duplicateHasher
+	if flags.DuplicatesFlag {
+		duplicates = newDuplicateHasher(flags.MinDuplicateLinesFlag)
+	}
 
+	lineNo := 0
 	for {
-		line, err := br.ReadBytes('\n')
-		if len(line) == 0 && err != nil {
+		raw, err := br.ReadBytes('\n')
+		if len(raw) == 0 && err != nil {
 			break
 		}
 
-		line = bytes.TrimSpace(line)
-
-		if len(line) == 0 {
-			langMetrics.Blanks++
-		} else if len(langDef.Type.SingleLine) > 0 && bytes.HasPrefix(line, []byte(langDef.Type.SingleLine)) {
-			langMetrics.Comments++
-			checkAnnotationsBytes(line, &annMetrics)
-		} else if len(langDef.Type.BlockStart) > 0 && bytes.Contains(line, []byte(langDef.Type.BlockStart)) {
-			i := bytes.Index(line, []byte(langDef.Type.BlockStart))
-			before := bytes.TrimSpace(line[:i])
-			after := line[i+len(langDef.Type.BlockStart):]
-
-			if len(langDef.Type.BlockEnd) > 0 && bytes.Contains(after, []byte(langDef.Type.BlockEnd)) {
-				j := bytes.Index(after, []byte(langDef.Type.BlockEnd))
-				left := before
-				right := bytes.TrimSpace(after[j+len(langDef.Type.BlockEnd):])
-
-				if len(left) > 0 || len(right) > 0 {
-					langMetrics.Code++
-				} else {
-					langMetrics.Comments++
-				}
+		// a lone '\r' ends a line too, for old Mac line endings
+		for len(raw) > 0 {
+			line := raw
+			if i := loneCarriageReturn(raw); i != -1 {
+				line, raw = raw[:i+1], raw[i+1:]
 			} else {
-				if len(before) > 0 {
-					langMetrics.Code++
-				} else {
-					langMetrics.Comments++
-				}
-				inBlockComment = true
+				raw = nil
 			}
+			lineNo++
 
-			checkAnnotationsBytes(line, &annMetrics)
-		} else if inBlockComment {
-			langMetrics.Comments++
-			if len(langDef.Type.BlockEnd) > 0 && bytes.Contains(line, []byte(langDef.Type.BlockEnd)) {
-				inBlockComment = false
+			physical := bytes.TrimRight(line, "\r\n")
+			line = bytes.TrimSpace(physical)
+			target, metrics := counter, &langMetrics
+			if splitter != nil {
+				if child := splitter.next(line, counter); child != nil {
+					if children == nil {
+						children = map[string]*LanguageMetrics{}
+						childCounters = map[string]*lineCounter{}
+					}
+					if children[child.Name] == nil {
+						children[child.Name] = &LanguageMetrics{Language: child.Name}
+						childCounters[child.Name] = newLineCounter(child)
+					}
+					target, metrics = childCounters[child.Name], children[child.Name]
+				}
+			}
+			target.startLine(line)
+			countLine(target, line, flags, metrics)
+			target.complexity.measure(physical, target.code, metrics)
+			if flags.FunctionMetricsFlag && target.functions != nil {
+				target.functions.track(lineNo, physical, target.code)
+			}
+			if duplicates != nil {
+				duplicates.add(lineNo, target.code)
+			}
+			if len(target.comment) > 0 {
+				tags.match(target.comment, lineNo, &annMetrics)
 			}
-		} else {
-			langMetrics.Code++
 		}
 
 		if err == io.EOF {
@@ -81,26 +127,415 @@ func countLinesInFile(r io.Reader, bufferSize int, langDef *LanguageDefinition)
 		}
 	}
 
+	var functions []FunctionMetrics
+	counter.complexity.finish(&langMetrics)
+	if flags.FunctionMetricsFlag && counter.functions != nil {
+		functions = counter.functions.close(lineNo)
+	}
+	for name, child := range children {
+		childCounters[name].complexity.finish(child)
+		if flags.FunctionMetricsFlag && childCounters[name].functions != nil {
+			functions = append(functions, childCounters[name].functions.close(lineNo)...)
+		}
+	}
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functions[i].StartLine < functions[j].StartLine
+	})
+	langMetrics.Children = sortedChildren(children)
 	langMetrics.Language = langDef.Name
 	langMetrics.Files = 1
-	langMetrics.Lines = langMetrics.Code + langMetrics.Comments + langMetrics.Blanks
 
 	/* example output
 	langMetrics = LanguageMetrics{ Language: "Go", Files: 1, Code: 100, Comments: 20, Blanks: 10, Lines: 130 }
 	annMetrics = AnnotationMetrics{ TotalTODO: 5, TotalFIXME: 2, TotalHACK: 1, TotalAnnotations: 8 }
 	error = nil
 	*/
-	return langMetrics, annMetrics, nil
+	count := fileCount{metrics: langMetrics, annotations: annMetrics, functions: functions}
+	if duplicates != nil {
+		count.duplicates = duplicates.fingerprints()
+	}
+	return count, nil
 }
 
-func checkAnnotationsBytes(line []byte, ann *AnnotationMetrics) {
+// loneCarriageReturn returns the index of the first '\r' in line that isn't part of a "\r\n", or -1.
+// A '\r' ending the last line of a file counts, since no '\n' follows it.
+func loneCarriageReturn(line []byte) int {
+	for offset := 0; ; {
+		i := bytes.IndexByte(line[offset:], '\r')
+		if i == -1 {
+			return -1
+		}
+		i += offset
+		if i+1 == len(line) {
+			return -1 // the line ends here anyway
+		}
+		if line[i+1] != '\n' {
+			return i
+		}
+		offset = i + 1
+	}
+}
+
+// countLine classifies a trimmed line with counter and adds it to metrics.
+func countLine(counter *lineCounter, line []byte, flags Config, metrics *LanguageMetrics) {
+	metrics.Lines++
+	switch counter.countLine(line) {
+	case lineBlank:
+		metrics.Blanks++
+	case lineComment:
+		metrics.Comments++
+	case lineDoc:
+		if flags.DocstringsAsCommentsFlag {
+			metrics.Comments++
+		} else {
+			metrics.Docs++
+		}
+	case lineMixed:
+		metrics.Mixed++
+		switch flags.MixedLinesFlag {
+		case MixedAsComment:
+			metrics.Comments++
+		case MixedAsBoth:
+			metrics.Code++
+			metrics.Comments++
+		default:
+			metrics.Code++
+		}
+	default:
+		metrics.Code++
+	}
+	metrics.DocComments += counter.docLines
+}
+
+type lineKind int
+
+const (
+	lineBlank lineKind = iota
+	lineCode
+	lineComment
+	lineDoc
+	lineMixed // code with a comment or docstring on the same line
+)
+
+// commentMarker starts a single-line comment, or a block comment if block is set.
+type commentMarker struct {
+	start string
+	block *BlockComment
+}
+
+// lineCounter is a small per-language lexer that classifies lines one at a time. It tracks string literals
+// so comment markers inside them (e.g. "/*" or "http://") are code, and carries block comments (with their
+// nesting depth), multiline strings and docstrings over to the following lines.
+type lineCounter struct {
+	markers     []commentMarker
+	firstColumn []string // markers that only start a comment at the start of a line
+	strings     []StringType
+	docMarkers  []string
+	docBefore   *regexp.Regexp
+	complexity  *complexityCounter
+	functions   *functionTracker // nil for languages without FunctionRules
+	firstBytes  [256]bool        // first bytes of every comment and string delimiter, to skip plain code quickly
+
+	block    *BlockComment // the block comment the line is in, if any
+	depth    int           // nesting depth of block, only above 1 for nested comments
+	docBlock bool          // block was opened with a doc marker (e.g. "/**")
+	inString *StringType
+	inDoc    bool   // inString is a docstring
+	column   string // the first column marker the line starts with, set by startLine
+
+	sawDoc      bool // the last line had a doc comment marker or continued a doc block
+	pendingDocs int  // comment lines directly above the current line, which are doc comments if it matches docBefore
+
+	comment  []byte // comment and docstring text of the last counted line, used for annotations
+	code     []byte // code of the last counted line, with comments and string literals left out, used for complexity
+	docLines int    // documentation lines found by the last counted line, which can include pendingDocs
+}
+
+// docBeforePatterns caches compiled LanguageDefinition.DocBefore patterns, so they aren't compiled for every file.
+var docBeforePatterns sync.Map
+
+func docBeforePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	if re, ok := docBeforePatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil // custom patterns are validated up front, so this only skips doc detection
+	}
+	docBeforePatterns.Store(pattern, re)
+	return re
+}
+
+func newLineCounter(langDef *LanguageDefinition) *lineCounter {
+	c := &lineCounter{
+		docMarkers:  langDef.Type.Doc,
+		firstColumn: langDef.Type.FirstColumn,
+		docBefore:   docBeforePattern(langDef.DocBefore),
+		complexity:  newComplexityCounter(langDef),
+		functions:   newFunctionTracker(langDef),
+	}
+	for _, marker := range langDef.Type.SingleLine {
+		c.markers = append(c.markers, commentMarker{start: marker})
+	}
+	for i := range langDef.Type.Blocks {
+		c.markers = append(c.markers, commentMarker{start: langDef.Type.Blocks[i].Start, block: &langDef.Type.Blocks[i]})
+	}
+	c.strings = append([]StringType(nil), langDef.Strings...)
+
+	// longest delimiter first, so "###" wins over "#", `"""` over `"` and `r#"` over `r"`
+	sort.SliceStable(c.markers, func(i, j int) bool {
+		return len(c.markers[i].start) > len(c.markers[j].start)
+	})
+	sort.SliceStable(c.strings, func(i, j int) bool {
+		return len(c.strings[i].Start) > len(c.strings[j].Start)
+	})
+
+	for _, marker := range c.markers {
+		if marker.start != "" {
+			c.firstBytes[marker.start[0]] = true
+		}
+	}
+	for _, str := range c.strings {
+		if str.Start != "" {
+			c.firstBytes[str.Start[0]] = true
+		}
+	}
+	return c
+}
+
+// startLine checks whether a trimmed line starts with a first column comment marker, before it is counted.
+// Markers inside a string or block comment don't count, and a marker ending in a letter (e.g. "rem") must be
+// followed by a non-word character, so "remote" isn't a comment.
+func (c *lineCounter) startLine(line []byte) {
+	c.column = ""
+	if c.inString != nil || c.block != nil {
+		return
+	}
+	for _, marker := range c.firstColumn {
+		if !bytes.HasPrefix(line, []byte(marker)) {
+			continue
+		}
+		if len(line) > len(marker) && isWordByte(marker[len(marker)-1]) && isWordByte(line[len(marker)]) {
+			continue
+		}
+		c.column = marker
+		return
+	}
+}
+
+// countLine classifies a trimmed line and works out how many documentation lines it accounts for.
+func (c *lineCounter) countLine(line []byte) lineKind {
+	kind := c.classify(line)
+
+	c.docLines = 0
+	switch {
+	case kind == lineDoc || (kind == lineComment && c.sawDoc):
+		c.docLines = 1
+		c.pendingDocs = 0
+	case c.docBefore == nil:
+	case kind == lineComment:
+		c.pendingDocs++
+	case (kind == lineCode || kind == lineMixed) && c.docBefore.Match(line):
+		c.docLines = c.pendingDocs
+		c.pendingDocs = 0
+	default:
+		c.pendingDocs = 0
+	}
+	return kind
+}
+
+// classify scans a trimmed line. A line with code outside comments is code, or mixed if it also has a comment.
+func (c *lineCounter) classify(line []byte) lineKind {
+	c.comment = c.comment[:0]
+	c.code = c.code[:0]
+	c.sawDoc = false
+	if len(line) == 0 {
+		return lineBlank
+	}
+	if c.column != "" {
+		c.comment = append(append(c.comment, ' '), line[len(c.column):]...)
+		return lineComment
+	}
+
+	hasCode := false
+	hasComment := false
+	hasDoc := false
+	for i := 0; i < len(line); {
+		switch {
+		case c.inString != nil:
+			start := i
+			if c.inDoc {
+				hasDoc = true
+				str := c.inString
+				i = c.skipString(line, i)
+				c.appendComment(line[start:i], c.inString == nil, str.End)
+			} else {
+				hasCode = true
+				i = c.skipString(line, i)
+			}
+
+		case c.block != nil:
+			hasComment = true
+			c.sawDoc = c.sawDoc || c.docBlock
+			start := i
+			block := c.block
+			i = c.skipBlock(line, i)
+			c.appendComment(line[start:i], c.block == nil, block.End)
+
+		case !c.firstBytes[line[i]]:
+			hasCode = true
+			start := i
+			for i < len(line) && !c.firstBytes[line[i]] {
+				i++
+			}
+			c.code = append(c.code, line[start:i]...)
+
+		default:
+			if marker := c.markerAt(line[i:]); marker != nil {
+				hasComment = true
+				c.code = append(c.code, ' ')
+				isDoc := c.docAt(line[i:])
+				c.sawDoc = c.sawDoc || isDoc
+				i += len(marker.start)
+				if marker.block == nil {
+					c.comment = append(append(c.comment, ' '), line[i:]...)
+					i = len(line)
+				} else {
+					c.block = marker.block
+					c.depth = 1
+					c.docBlock = isDoc
+				}
+				break
+			}
+			if str := c.stringAt(line[i:]); str != nil {
+				c.inString = str
+				// a docstring is a literal statement on its own, anything before it makes it a regular string
+				c.inDoc = str.Doc && !hasCode
+				if c.inDoc {
+					hasDoc = true
+				} else {
+					hasCode = true
+					c.code = append(c.code, ' ') // the literal's content can't hold branches
+				}
+				i += len(str.Start)
+				break
+			}
+			hasCode = true
+			c.code = append(c.code, line[i])
+			i++
+		}
+	}
+
+	// only multiline literals carry over, an unterminated single-line string ends with its line
+	if c.inString != nil && !c.inString.Multiline {
+		c.inString = nil
+		c.inDoc = false
+	}
+
 	switch {
-	case bytes.Contains(line, []byte("TODO")):
-		ann.TotalTODO++
-	case bytes.Contains(line, []byte("FIXME")):
-		ann.TotalFIXME++
-	case bytes.Contains(line, []byte("HACK")):
-		ann.TotalHACK++
-	}
-	ann.TotalAnnotations = ann.TotalTODO + ann.TotalFIXME + ann.TotalHACK
+	case hasCode && (hasComment || hasDoc):
+		return lineMixed
+	case hasCode:
+		return lineCode
+	case hasDoc:
+		return lineDoc
+	case hasComment:
+		return lineComment
+	default:
+		return lineBlank
+	}
+}
+
+// appendComment adds comment text for annotations, without the closing delimiter if the comment closed.
+func (c *lineCounter) appendComment(text []byte, closed bool, end string) {
+	if closed {
+		text = text[:len(text)-len(end)]
+	}
+	c.comment = append(append(c.comment, ' '), text...)
+}
+
+func (c *lineCounter) markerAt(line []byte) *commentMarker {
+	for i := range c.markers {
+		if c.markers[i].start != "" && bytes.HasPrefix(line, []byte(c.markers[i].start)) {
+			return &c.markers[i]
+		}
+	}
+	return nil
+}
+
+// docAt reports whether the comment line starts with is a doc comment. A doc marker followed by its own
+// last character isn't one, so "////" or "/***" banners aren't taken for "///" or "/**".
+func (c *lineCounter) docAt(line []byte) bool {
+	for _, marker := range c.docMarkers {
+		if marker == "" || !bytes.HasPrefix(line, []byte(marker)) {
+			continue
+		}
+		if len(line) == len(marker) || line[len(marker)] != marker[len(marker)-1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *lineCounter) stringAt(line []byte) *StringType {
+	for i := range c.strings {
+		if c.strings[i].Start != "" && bytes.HasPrefix(line, []byte(c.strings[i].Start)) {
+			return &c.strings[i]
+		}
+	}
+	return nil
+}
+
+// skipString moves past the end of the current string literal from line[i:], or to the end of the line
+// if the literal doesn't close on it.
+func (c *lineCounter) skipString(line []byte, i int) int {
+	str := c.inString
+	for i < len(line) {
+		if str.Escape != "" && bytes.HasPrefix(line[i:], []byte(str.Escape)) {
+			i += len(str.Escape) + 1
+			continue
+		}
+		if bytes.HasPrefix(line[i:], []byte(str.End)) {
+			c.inString = nil
+			c.inDoc = false
+			return i + len(str.End)
+		}
+		i++
+	}
+	return len(line)
+}
+
+// skipBlock moves past the end of the current block comment from line[i:], or to the end of the line
+// if the comment doesn't close on it. Nested comments only close once every inner comment has.
+func (c *lineCounter) skipBlock(line []byte, i int) int {
+	block := c.block
+	if !block.Nested {
+		end := bytes.Index(line[i:], []byte(block.End))
+		if end == -1 {
+			return len(line)
+		}
+		c.block = nil
+		return i + end + len(block.End)
+	}
+
+	for i < len(line) {
+		switch {
+		case bytes.HasPrefix(line[i:], []byte(block.Start)):
+			c.depth++
+			i += len(block.Start)
+		case bytes.HasPrefix(line[i:], []byte(block.End)):
+			c.depth--
+			i += len(block.End)
+			if c.depth == 0 {
+				c.block = nil
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(line)
 }
@@ -0,0 +1,276 @@
+package pathfinder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountLinesInFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		code     int
+		comments int
+		blanks   int
+		docs     int
+		todos    int
+		mixed    int
+
+		docComments    int
+		docsAsComments bool
+		mixedLines     MixedLineMode
+	}{
+		{
+			name:     "block marker in string",
+			language: "Go",
+			content:  "path := \"/*\"\nx := 1\n// done\n",
+			code:     2,
+			comments: 1,
+		},
+		{
+			name:     "url in string",
+			language: "Go",
+			content:  "url := \"http://example.com\" // TODO: move to config\n\n",
+			code:     1,
+			blanks:   1,
+			todos:    1,
+			mixed:    1,
+		},
+		{
+			name:     "escaped quote",
+			language: "C",
+			content:  "char *s = \"say \\\"/*\\\"\";\nint x = 1;\n",
+			code:     2,
+		},
+		{
+			name:     "char literal",
+			language: "Java",
+			content:  "char quote = '\"';\n// comment\n",
+			code:     1,
+			comments: 1,
+		},
+		{
+			name:     "multiline raw string",
+			language: "Go",
+			content:  "s := `\n// not a comment\n/* nor this\n`\n",
+			code:     4,
+		},
+		{
+			name:     "template literal",
+			language: "TypeScript",
+			content:  "const q = `\n  /* ${table} */\n`;\n/* real\n comment */\n",
+			code:     3,
+			comments: 2,
+		},
+		{
+			name:     "block comment with trailing code",
+			language: "C",
+			content:  "/* a */ int x; /* b\n still b */\n/* c */\n",
+			code:     1,
+			comments: 2,
+			mixed:    1,
+		},
+		{
+			name:     "string in block comment",
+			language: "Go",
+			content:  "/* \"\n*/\nx := 1\n",
+			code:     1,
+			comments: 2,
+		},
+		{
+			name:     "longer block marker wins",
+			language: "CoffeeScript",
+			content:  "###\nblock\n###\n# line\nx = 1\n",
+			code:     1,
+			comments: 4,
+		},
+		{
+			name:     "unterminated single-line string",
+			language: "Python",
+			content:  "x = 'oops\n# comment\n",
+			code:     1,
+			comments: 1,
+		},
+		{
+			name:     "annotation in string is not counted",
+			language: "Python",
+			content:  "msg = \"TODO\"\n",
+			code:     1,
+		},
+		{
+			name:     "several line markers",
+			language: "PHP",
+			content:  "<?php\n# hash comment\n// slash comment\n$x = 1;\n",
+			code:     2,
+			comments: 2,
+		},
+		{
+			name:     "nested block comment",
+			language: "Rust",
+			content:  "/* outer\n/* inner */\nstill outer */\nfn main() {}\n",
+			code:     1,
+			comments: 3,
+		},
+		{
+			name:     "non-nested block ends at first end marker",
+			language: "C",
+			content:  "/* a /* b */\nint x;\n",
+			code:     1,
+			comments: 1,
+		},
+		{
+			name:     "haskell nested comment",
+			language: "Haskell",
+			content:  "{- a {- b -} c -}\nmain = pure ()\n",
+			code:     1,
+			comments: 1,
+		},
+		{
+			name:     "lua long comment",
+			language: "Lua",
+			content:  "--[[\nlocal x = 1\n]]\n-- line\nprint(1)\n",
+			code:     1,
+			comments: 4,
+		},
+		{
+			name:     "ruby begin end",
+			language: "Ruby",
+			content:  "=begin\nputs 1\n=end\nputs 2\n",
+			code:     1,
+			comments: 3,
+		},
+		{
+			name:        "python docstrings",
+			language:    "Python",
+			content:     "def f():\n    \"\"\"Summary.\n\n    TODO: more\n    \"\"\"\n    return '''not a docstring'''\n",
+			code:        2,
+			blanks:      1,
+			docs:        3,
+			todos:       1,
+			docComments: 3,
+		},
+		{
+			name:           "docstrings as comments",
+			language:       "Python",
+			content:        "\"\"\"Module docs.\"\"\"\nx = \"\"\"\nvalue\n\"\"\"\n",
+			docsAsComments: true,
+			code:           3,
+			comments:       1,
+			docComments:    1,
+		},
+		{
+			name:        "elixir doc attribute",
+			language:    "Elixir",
+			content:     "@doc \"\"\"\nAdds numbers.\n\"\"\"\ndef add(a, b), do: a + b\n",
+			code:        1,
+			docs:        3,
+			docComments: 3,
+		},
+		{
+			name:        "rust doc comments",
+			language:    "Rust",
+			content:     "//! Crate docs.\n/// Adds.\n//// banner\n// plain\nfn add() {}\n",
+			code:        1,
+			comments:    4,
+			docComments: 2,
+		},
+		{
+			name:        "javadoc block",
+			language:    "Java",
+			content:     "/**\n * Adds.\n */\n/* plain\n */\nint add();\n",
+			code:        1,
+			comments:    5,
+			docComments: 3,
+		},
+		{
+			name:        "kotlin has no rust doc markers",
+			language:    "Kotlin",
+			content:     "//! banner\n/*! license */\n/** Adds. */\nfun add() {}\n",
+			code:        1,
+			comments:    3,
+			docComments: 1,
+		},
+		{
+			name:     "json5 has no doc comments",
+			language: "JSON5",
+			content:  "/** settings */\n/// note\n{a: 1}\n",
+			code:     1,
+			comments: 2,
+		},
+		{
+			name:     "abap first column and end of line comments",
+			language: "ABAP",
+			content:  "* header\nDATA x TYPE i. \" counter\n  x = 2 * 3.\nWRITE 'a \" b'.\n  \" indented\n",
+			code:     3,
+			comments: 2,
+			mixed:    1,
+		},
+		{
+			name:     "batch rem and label comments",
+			language: "Batch",
+			content:  "REM build\nset REMOTE=1\necho PREMIUM\n  rem indented\n:: label comment\nremove.exe\n",
+			code:     3,
+			comments: 3,
+		},
+		{
+			name:        "go comments before exported declarations",
+			language:    "Go",
+			content:     "// Package demo is documented.\npackage demo\n\n// Add adds.\n// It is exported.\nfunc Add() {}\n\n// helper isn't exported.\nfunc helper() {}\n\n// detached\n\nfunc (s *S) Method() {}\n\ntype T struct {\n\t// Name is documented.\n\tName string\n\t// count isn't exported.\n\tcount int\n}\n",
+			code:        8,
+			comments:    7,
+			blanks:      5,
+			docComments: 4,
+		},
+		{
+			name:       "mixed lines as comments",
+			language:   "Go",
+			content:    "x := 1 // set x\ny := 2\n",
+			mixedLines: MixedAsComment,
+			code:       1,
+			comments:   1,
+			mixed:      1,
+		},
+		{
+			name:       "mixed lines as both",
+			language:   "C",
+			content:    "/* a */ b();\nc();\n",
+			mixedLines: MixedAsBoth,
+			code:       2,
+			comments:   1,
+			mixed:      1,
+		},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			langDef := languages.determineLangByName(tt.language)
+			count, err := countLinesInFile(strings.NewReader(tt.content), Config{
+				BufferSizeFlag:           4096,
+				DocstringsAsCommentsFlag: tt.docsAsComments,
+				MixedLinesFlag:           tt.mixedLines,
+			}, languages, langDef)
+			if err != nil {
+				t.Fatalf("countLinesInFile() error = %v", err)
+			}
+			got, ann := count.metrics, count.annotations
+			if got.Code != tt.code || got.Comments != tt.comments || got.Blanks != tt.blanks || got.Docs != tt.docs {
+				t.Fatalf("countLinesInFile() = %d code, %d comments, %d blanks, %d docs, want %d, %d, %d, %d",
+					got.Code, got.Comments, got.Blanks, got.Docs, tt.code, tt.comments, tt.blanks, tt.docs)
+			}
+			if got.Mixed != tt.mixed {
+				t.Fatalf("Mixed = %d, want %d", got.Mixed, tt.mixed)
+			}
+			if lines := strings.Count(tt.content, "\n"); got.Lines != lines {
+				t.Fatalf("Lines = %d, want %d", got.Lines, lines)
+			}
+			if got.DocComments != tt.docComments {
+				t.Fatalf("DocComments = %d, want %d", got.DocComments, tt.docComments)
+			}
+			if ann.TotalTODO != tt.todos {
+				t.Fatalf("TotalTODO = %d, want %d", ann.TotalTODO, tt.todos)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package pathfinder
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sniffSize is how much of a file is read to detect its language from content.
+const sniffSize = 8 * 1024
+
+// modelineTailSize is how much of the end of a file is checked for modelines, which is where emacs
+// looks for a "Local Variables:" block. Vim reads modelines from the first and last 5 lines.
+const modelineTailSize = 3000
+
+// languageHeuristics tell apart languages sharing an extension (e.g. ".h" or ".m") by their content.
+// Candidates are checked in definition order. If none of them match, the first candidate without a heuristic
+// is used as the default (e.g. C for ".h"), falling back to the first candidate.
+var languageHeuristics = map[string]*regexp.Regexp{
+	"C++":         regexp.MustCompile(`(?m)^\s*(class\s+\w+|namespace\s+\w*|template\s*<|#include\s*<(iostream|string|vector|memory|map|algorithm)>)|std::|\b(public|private|protected)\s*:`),
+	"Objective-C": regexp.MustCompile(`(?m)^\s*(@interface|@implementation|@protocol|@property|@end|#import)\b`),
+	"MATLAB":      regexp.MustCompile(`(?m)^\s*(function\s|%|end\s*;?\s*$)`),
+	"Coq":         regexp.MustCompile(`(?m)^\s*(Theorem|Lemma|Proof|Qed|Definition|Fixpoint|Inductive|Require\s+Import)\b`),
+	"Prolog":      regexp.MustCompile(`(?m)^\s*:-|^[a-z]\w*(\(.*\))?\s*:-`),
+}
+
+var (
+	vimModeline   = regexp.MustCompile(`\b(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syntax)=([\w+-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:mode:\s*)?([\w+-]+)\s*(?:;.*)?-\*-`)
+	emacsLocals   = regexp.MustCompile(`(?s)Local Variables:.*?[^\w-]mode:\s*([\w+-]+).*?End:`)
+)
+
+// languageAliases maps editor modes, code fence info strings and notebook kernels that don't match
+// a language name, extension or interpreter.
+var languageAliases = map[string]string{
+	"make":   "Makefile",
+	"objc":   "Objective-C",
+	"cpp":    "C++",
+	"c++":    "C++",
+	"golang": "Go",
+	"csharp": "C#",
+}
+
+// detectLanguage resolves the language of a file whose name alone isn't enough: either an extensionless
+// file (shebang or modeline) or an extension shared by several candidates (content heuristics).
+func (r *languageRegistry) detectLanguage(path string, candidates []*LanguageDefinition) (*LanguageDefinition, error) {
+	head, err := readHead(path, sniffSize)
+	if err != nil {
+		return nil, err
+	}
+	if looksBinary(head) {
+		return nil, nil // binary content is never counted
+	}
+	// the raw length tells if the file goes on past the head, decoding can change it
+	rawLength := len(head)
+	enc, bomLength := detectEncoding(head)
+	head = decodeBytes(head[bomLength:], enc)
+
+	if len(candidates) > 0 {
+		return disambiguateLanguage(candidates, head), nil
+	}
+	if langDef := r.languageFromShebang(head); langDef != nil {
+		return langDef, nil
+	}
+
+	tail := head
+	if rawLength == sniffSize {
+		// the file is longer than the sniffed head, so its last lines need a read of their own
+		if tail, err = readTail(path, modelineTailSize); err != nil {
+			return nil, err
+		}
+		tail = decodeBytes(tail, enc)
+	}
+	return r.languageFromModeline(head, tail), nil
+}
+
+func readHead(path string, size int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, size)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// readTail reads up to the last size bytes of a file.
+func readTail(path string, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := max(info.Size()-size, 0)
+	tail := make([]byte, info.Size()-offset)
+	n, err := f.ReadAt(tail, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return tail[:n], nil
+}
+
+func disambiguateLanguage(candidates []*LanguageDefinition, head []byte) *LanguageDefinition {
+	var fallback *LanguageDefinition
+	for _, candidate := range candidates {
+		heuristic, ok := languageHeuristics[candidate.Name]
+		if !ok {
+			if fallback == nil {
+				fallback = candidate
+			}
+			continue
+		}
+		if heuristic.Match(head) {
+			return candidate
+		}
+	}
+
+	if fallback != nil {
+		return fallback
+	}
+	return candidates[0]
+}
+
+// languageFromShebang parses lines like "#!/bin/bash" or "#!/usr/bin/env -S python3 -u".
+func (r *languageRegistry) languageFromShebang(head []byte) *LanguageDefinition {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return nil
+	}
+
+	line, _, _ := bytes.Cut(head[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			// skip env flags (-S, -i, ...) and VAR=value assignments
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = filepath.Base(field)
+			break
+		}
+	}
+
+	if langDef := r.determineLangByInterpreter(interpreter); langDef != nil {
+		return langDef
+	}
+	// fall back to the unversioned interpreter, e.g. "python3.12" -> "python"
+	return r.determineLangByInterpreter(strings.TrimRight(interpreter, "0123456789."))
+}
+
+// languageFromModeline looks for vim ("vim: set ft=python:") or emacs ("-*- mode: ruby -*-") modelines in the
+// first and last 5 lines, like vim does, and for an emacs "Local Variables:" block with a mode at the end of the file.
+func (r *languageRegistry) languageFromModeline(head, tail []byte) *LanguageDefinition {
+	lines := bytes.SplitN(head, []byte("\n"), 6)
+	if len(lines) > 5 {
+		lines = lines[:5]
+	}
+	lines = append(lines, lastLines(tail, 5)...)
+
+	for _, line := range lines {
+		var mode string
+		if match := vimModeline.FindSubmatch(line); match != nil {
+			mode = string(match[1])
+		} else if match := emacsModeline.FindSubmatch(line); match != nil {
+			mode = string(match[1])
+		} else {
+			continue
+		}
+
+		if langDef := r.resolveLanguage(mode); langDef != nil {
+			return langDef
+		}
+	}
+
+	if match := emacsLocals.FindSubmatch(tail); match != nil {
+		return r.resolveLanguage(string(match[1]))
+	}
+	return nil
+}
+
+// lastLines returns up to the last n lines of text, ignoring a trailing newline.
+func lastLines(text []byte, n int) [][]byte {
+	text = bytes.TrimSuffix(text, []byte("\n"))
+	lines := bytes.Split(text, []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// resolveLanguage finds a language from a loose name like a modeline mode ("python"), a code fence
+// info string ("js") or a notebook kernel language, trying names, interpreters and then extensions.
+func (r *languageRegistry) resolveLanguage(name string) *LanguageDefinition {
+	name = strings.ToLower(name)
+	if name == "" {
+		return nil
+	}
+	if alias, ok := languageAliases[name]; ok {
+		name = alias
+	}
+	if langDef := r.determineLangByName(name); langDef != nil {
+		return langDef
+	}
+	if langDef := r.determineLangByInterpreter(name); langDef != nil {
+		return langDef
+	}
+	if candidates := r.determineLangByExt("." + name); len(candidates) > 0 {
+		return candidates[0]
+	}
+	return nil
+}
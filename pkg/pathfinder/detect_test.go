@@ -0,0 +1,107 @@
+package pathfinder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	utf16le := func(s string) string {
+		b := []byte{0xFF, 0xFE}
+		for _, unit := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(unit), byte(unit>>8))
+		}
+		return string(b)
+	}
+	longPython := strings.Repeat("print(1)\n", 2000) + "# vim: set ft=python:\n"
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "env shebang", file: "deploy", content: "#!/usr/bin/env python3\nprint('hi')\n", want: "Python"},
+		{name: "env -S shebang", file: "serve", content: "#!/usr/bin/env -S node --no-warnings\nconsole.log(1)\n", want: "JavaScript"},
+		{name: "absolute shebang", file: "build", content: "#!/bin/bash\necho hi\n", want: "Shell"},
+		{name: "versioned interpreter", file: "tool", content: "#!/usr/local/bin/python3.12\n", want: "Python"},
+		{name: "vim modeline", file: "rules", content: "# vim: set ft=ruby:\nputs 1\n", want: "Ruby"},
+		{name: "emacs modeline", file: "conf", content: "# -*- mode: sh; -*-\necho hi\n", want: "Shell"},
+		{name: "vim modeline at the end", file: "tasks", content: "puts 1\n" + strings.Repeat("puts 2\n", 2000) + "# vim: ft=ruby\n", want: "Ruby"},
+		{name: "vim modeline at the end after a bom", file: "bom", content: "\xEF\xBB\xBF" + longPython, want: "Python"},
+		{name: "vim modeline at the end in utf-16", file: "wide", content: utf16le(longPython), want: "Python"},
+		{name: "emacs local variables", file: "setup", content: "echo hi\n\n# Local Variables:\n# mode: sh\n# End:\n", want: "Shell"},
+		{name: "plain text", file: "LICENSE", content: "MIT License\n", want: ""},
+		{name: "binary", file: "blob", content: "#!/bin/sh\x00\x01", want: ""},
+		{name: "c header", file: "util.h", content: "#include <stdio.h>\nint add(int a, int b);\n", want: "C"},
+		{name: "c++ header", file: "vec.h", content: "#include <vector>\nnamespace geo {\nclass Vec {};\n}\n", want: "C++"},
+		{name: "objective-c header", file: "view.h", content: "#import <UIKit/UIKit.h>\n@interface View : UIView\n@end\n", want: "Objective-C"},
+		{name: "matlab", file: "solve.m", content: "% solve the system\nfunction x = solve(A, b)\n  x = A \\ b;\nend\n", want: "MATLAB"},
+		{name: "objective-c source", file: "view.m", content: "#import \"View.h\"\n@implementation View\n@end\n", want: "Objective-C"},
+		{name: "verilog default", file: "alu.v", content: "module alu(input a, output y);\nendmodule\n", want: "Verilog"},
+		{name: "coq", file: "proof.v", content: "Require Import Arith.\nTheorem plus_0 : forall n, n + 0 = n.\nProof. auto. Qed.\n", want: "Coq"},
+		{name: "perl default", file: "run.pl", content: "use strict;\nprint \"hi\\n\";\n", want: "Perl"},
+		{name: "prolog", file: "family.pl", content: "parent(tom, bob).\ngrandparent(X, Z) :- parent(X, Y), parent(Y, Z).\n", want: "Prolog"},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			langDef, err := languages.detectLanguage(path, languages.determineLangByExt(hasNoExt(tt.file)))
+			if err != nil {
+				t.Fatalf("detectLanguage() error = %v", err)
+			}
+
+			got := ""
+			if langDef != nil {
+				got = langDef.Name
+			}
+			if got != tt.want {
+				t.Fatalf("detectLanguage(%s) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanDetectsFilenames(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"Makefile":    "build:\n\tgo build ./...\n",
+		"Dockerfile":  "FROM golang:1.24\n",
+		"Jenkinsfile": "pipeline {}\n",
+		"bin/run":     "#!/bin/sh\necho run\n",
+		"README":      "docs\n",
+	})
+
+	report, err := Scan(Config{PathFlag: root, RecursiveFlag: true})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	got := map[string]string{}
+	for _, file := range report.FileMetrics {
+		got[filepath.ToSlash(file.Path)] = file.Metrics.Language
+	}
+	want := map[string]string{
+		"Makefile":    "Makefile",
+		"Dockerfile":  "Dockerfile",
+		"Jenkinsfile": "Groovy",
+		"bin/run":     "Shell",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("scanned files = %v, want %v", got, want)
+	}
+	for path, lang := range want {
+		if got[path] != lang {
+			t.Fatalf("language of %s = %q, want %q", path, got[path], lang)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package pathfinder
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultMinDuplicateLines is used when Config.MinDuplicateLinesFlag is 0.
+	defaultMinDuplicateLines = 6
+
+	// winnowWindow is how many consecutive window hashes each fingerprint is picked from. Every duplicated
+	// block of at least MinDuplicateLinesFlag+winnowWindow-1 lines is guaranteed to share a fingerprint.
+	winnowWindow = 3
+
+	// maxDuplicateLines bounds the code lines buffered for the duplicate index, so memory doesn't grow with
+	// the size of the codebase. Files past it in path order are left out.
+	maxDuplicateLines = 1 << 22
+
+	// duplicateBlocksLimit is how many blocks DuplicateMetrics.Blocks keeps.
+	duplicateBlocksLimit = 100
+)
+
+// fingerprint is the hash of a window of code lines, starting at the index-th code line of a file.
+type fingerprint struct {
+	hash  uint64
+	index int32
+}
+
+// duplicateFingerprints is what a worker hashes from a file for the duplicate index (see duplicateHasher).
+type duplicateFingerprints struct {
+	window   int      // code lines per fingerprint
+	fileHash uint64   // hash of every code line, to find identical files
+	lines    []uint64 // hash of each code line, to grow matches to the whole duplicated block
+	lineNos  []int32  // line number of each code line
+	prints   []fingerprint
+}
+
+// duplicateHasher hashes the code lines of a file. Whitespace is collapsed and lines without letters or
+// digits (e.g. "}" or "});") are left out, and so are comments and string literals (see lineCounter.code),
+// so copies that only differ in formatting, comments or strings still match.
+type duplicateHasher struct {
+	window  int
+	lines   []uint64
+	lineNos []int32
+}
+
+func newDuplicateHasher(minLines int) *duplicateHasher {
+	return &duplicateHasher{window: minLines}
+}
+
+// add hashes the code of a line, if it has any worth comparing.
+func (h *duplicateHasher) add(lineNo int, code []byte) {
+	if hash, ok := hashCodeLine(code); ok {
+		h.lines = append(h.lines, hash)
+		h.lineNos = append(h.lineNos, int32(lineNo))
+	}
+}
+
+// fingerprints winnows the window hashes of the file: of every winnowWindow consecutive windows, the one
+// with the smallest hash (the rightmost on ties) is kept, so a file keeps about 2/(winnowWindow+1) of them.
+func (h *duplicateHasher) fingerprints() *duplicateFingerprints {
+	result := &duplicateFingerprints{window: h.window, fileHash: offset64, lines: h.lines, lineNos: h.lineNos}
+	for _, line := range h.lines {
+		result.fileHash = mixHash(result.fileHash, line)
+	}
+	if len(h.lines) < h.window {
+		return result
+	}
+
+	windows := make([]uint64, len(h.lines)-h.window+1)
+	for i := range windows {
+		hash := uint64(offset64)
+		for _, line := range h.lines[i : i+h.window] {
+			hash = mixHash(hash, line)
+		}
+		windows[i] = hash
+	}
+
+	last := -1
+	for start := 0; start == 0 || start+winnowWindow <= len(windows); start++ {
+		end := min(start+winnowWindow, len(windows))
+		picked := start
+		for i := start; i < end; i++ {
+			if windows[i] <= windows[picked] {
+				picked = i
+			}
+		}
+		if picked != last {
+			result.prints = append(result.prints, fingerprint{hash: windows[picked], index: int32(picked)})
+			last = picked
+		}
+	}
+	return result
+}
+
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+// hashCodeLine hashes code with FNV-1a, with runs of whitespace as a single space. Code without letters
+// or digits isn't worth comparing and returns false.
+func hashCodeLine(code []byte) (uint64, bool) {
+	hash := uint64(offset64)
+	significant, started, space := false, false, false
+	for _, b := range code {
+		switch {
+		case b == ' ' || b == '\t' || b == '\r' || b == '\v' || b == '\f':
+			space = started
+			continue
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b >= 0x80:
+			significant = true
+		}
+		if space {
+			hash = (hash ^ ' ') * prime64
+			space = false
+		}
+		hash = (hash ^ uint64(b)) * prime64
+		started = true
+	}
+	return hash, significant
+}
+
+func mixHash(hash, value uint64) uint64 {
+	for i := 0; i < 64; i += 8 {
+		hash = (hash ^ (value >> i & 0xff)) * prime64
+	}
+	return hash
+}
+
+// fingerprintLocation is where a fingerprint was first seen: the file and the index of its first code line.
+type fingerprintLocation struct {
+	file  int32
+	index int32
+}
+
+// duplicateRun is a duplicated block in the file being compared, and where its copy is.
+type duplicateRun struct {
+	file       int32
+	delta      int32 // index in the current file minus the index of the copy in file
+	start, end int32 // indexes of the first code line of the block and the one after it
+}
+
+// duplicateFile is a file buffered for the duplicate index.
+type duplicateFile struct {
+	path   string
+	prints *duplicateFingerprints
+}
+
+// duplicateFiles is a max-heap of buffered files by path, so the last one in path order can be dropped.
+type duplicateFiles []duplicateFile
+
+func (f duplicateFiles) Len() int           { return len(f) }
+func (f duplicateFiles) Less(i, j int) bool { return f[i].path > f[j].path }
+func (f duplicateFiles) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f *duplicateFiles) Push(x any)        { *f = append(*f, x.(duplicateFile)) }
+func (f *duplicateFiles) Pop() any {
+	old := *f
+	file := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return file
+}
+
+// duplicateIndex finds duplicates between the files of a scan. Files arrive in the order workers finish
+// them, so they are buffered and compared in path order when the report is built: every file is compared
+// with the ones before it, so the copy with the first path is the original and the rest count as duplicated.
+type duplicateIndex struct {
+	pending      duplicateFiles
+	pendingLines int
+	cutoff       string // once files have been dropped, the first dropped path; files at or after it are dropped too
+
+	compared     []duplicateFile // by file id
+	fingerprints map[uint64]fingerprintLocation
+	files        map[uint64]int32              // whole-file hash to the first file with it
+	groups       map[int32]*DuplicateFileGroup // by the first file of the group
+	metrics      DuplicateMetrics
+}
+
+func newDuplicateIndex() *duplicateIndex {
+	return &duplicateIndex{
+		fingerprints: map[uint64]fingerprintLocation{},
+		files:        map[uint64]int32{},
+		groups:       map[int32]*DuplicateFileGroup{},
+	}
+}
+
+// add buffers a file until build. Past maxDuplicateLines, the files last in path order are dropped, so the
+// files kept are the same whatever order they arrive in.
+func (d *duplicateIndex) add(relPath string, prints *duplicateFingerprints) {
+	if d.metrics.Partial && relPath >= d.cutoff {
+		return
+	}
+	heap.Push(&d.pending, duplicateFile{path: relPath, prints: prints})
+	d.pendingLines += len(prints.lines)
+	for d.pendingLines > maxDuplicateLines {
+		last := heap.Pop(&d.pending).(duplicateFile)
+		d.pendingLines -= len(last.prints.lines)
+		if !d.metrics.Partial || last.path < d.cutoff {
+			d.cutoff = last.path
+		}
+		d.metrics.Partial = true
+	}
+}
+
+// compare compares a file with the files compared before it, then adds its fingerprints to the index.
+func (d *duplicateIndex) compare(file duplicateFile) {
+	id := int32(len(d.compared))
+	d.compared = append(d.compared, file)
+	prints := file.prints
+	lines := len(prints.lines)
+	d.metrics.Files++
+	d.metrics.Lines += lines
+
+	// files too small to hold a block aren't reported as identical either, e.g. empty __init__.py files
+	if lines >= prints.window {
+		if first, ok := d.files[prints.fileHash]; ok {
+			group := d.groups[first]
+			if group == nil {
+				group = &DuplicateFileGroup{Lines: lines, Paths: []string{d.compared[first].path}}
+				d.groups[first] = group
+			}
+			group.Paths = append(group.Paths, file.path)
+			d.metrics.DuplicatedLines += lines
+			return
+		}
+		d.files[prints.fileHash] = id
+	}
+
+	window := int32(prints.window)
+	duplicated := make([]bool, lines)
+	var runs []duplicateRun
+	for _, p := range prints.prints {
+		location, ok := d.fingerprints[p.hash]
+		if !ok {
+			d.fingerprints[p.hash] = fingerprintLocation{file: id, index: p.index}
+			continue
+		}
+
+		delta := p.index - location.index
+		if location.file == id && delta < window {
+			continue // a block overlapping its own copy, e.g. a run of repeated lines
+		}
+		covered := false
+		for _, run := range runs {
+			if run.file == location.file && run.delta == delta && p.index >= run.start && p.index+window <= run.end {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		other := d.compared[location.file].prints
+		run, ok := extendMatch(prints.lines, other.lines, p.index, delta, window, location.file == id)
+		if !ok {
+			continue
+		}
+		run.file = location.file
+		runs = append(runs, run)
+		for i := run.start; i < run.end; i++ {
+			duplicated[i] = true
+		}
+		d.addBlock(DuplicateBlock{
+			Lines: int(run.end - run.start),
+			Locations: []DuplicateLocation{
+				{Path: d.compared[run.file].path, StartLine: int(other.lineNos[run.start-delta]), EndLine: int(other.lineNos[run.end-delta-1])},
+				{Path: file.path, StartLine: int(prints.lineNos[run.start]), EndLine: int(prints.lineNos[run.end-1])},
+			},
+		})
+	}
+
+	for _, dup := range duplicated {
+		if dup {
+			d.metrics.DuplicatedLines++
+		}
+	}
+}
+
+// extendMatch grows the window at index in lines, whose copy starts delta lines earlier in other, to every
+// code line around it that matches too. It returns false if the window itself doesn't match, which means two
+// different windows had the same hash. Within one file, the block stops where it would overlap its copy.
+func extendMatch(lines, other []uint64, index, delta, window int32, sameFile bool) (duplicateRun, bool) {
+	for i := index; i < index+window; i++ {
+		if lines[i] != other[i-delta] {
+			return duplicateRun{}, false
+		}
+	}
+
+	fits := func(start, end int32) bool {
+		return !sameFile || end-delta <= start
+	}
+	start, end := index, index+window
+	for start > 0 && start-delta > 0 && fits(start-1, end) && lines[start-1] == other[start-1-delta] {
+		start--
+	}
+	for end < int32(len(lines)) && end-delta < int32(len(other)) && fits(start, end+1) && lines[end] == other[end-delta] {
+		end++
+	}
+	return duplicateRun{delta: delta, start: start, end: end}, true
+}
+
+// addBlock keeps the longest blocks, trimming them now and then so memory stays bounded.
+func (d *duplicateIndex) addBlock(block DuplicateBlock) {
+	sort.Slice(block.Locations, func(i, j int) bool {
+		a, b := block.Locations[i], block.Locations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.StartLine < b.StartLine
+	})
+	d.metrics.Blocks = append(d.metrics.Blocks, block)
+	if len(d.metrics.Blocks) >= 2*duplicateBlocksLimit {
+		d.metrics.Blocks = longestBlocks(d.metrics.Blocks)
+	}
+}
+
+func longestBlocks(blocks []DuplicateBlock) []DuplicateBlock {
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].Lines != blocks[j].Lines {
+			return blocks[i].Lines > blocks[j].Lines
+		}
+		a, b := blocks[i].Locations[0], blocks[j].Locations[0]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.StartLine < b.StartLine
+	})
+	if len(blocks) > duplicateBlocksLimit {
+		blocks = blocks[:duplicateBlocksLimit]
+	}
+	return blocks
+}
+
+func (d *duplicateIndex) build() DuplicateMetrics {
+	files := d.pending
+	d.pending = nil
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	for _, file := range files {
+		d.compare(file)
+	}
+
+	metrics := d.metrics
+	if metrics.Lines > 0 {
+		metrics.Percentage = float64(metrics.DuplicatedLines) / float64(metrics.Lines) * 100
+	}
+	metrics.Blocks = longestBlocks(metrics.Blocks)
+
+	for _, group := range d.groups {
+		sort.Strings(group.Paths)
+		metrics.DuplicateFiles = append(metrics.DuplicateFiles, *group)
+	}
+	sort.Slice(metrics.DuplicateFiles, func(i, j int) bool {
+		a, b := metrics.DuplicateFiles[i], metrics.DuplicateFiles[j]
+		if a.Lines*(len(a.Paths)-1) != b.Lines*(len(b.Paths)-1) {
+			return a.Lines*(len(a.Paths)-1) > b.Lines*(len(b.Paths)-1)
+		}
+		return strings.Join(a.Paths, "\x00") < strings.Join(b.Paths, "\x00")
+	})
+	return metrics
+}
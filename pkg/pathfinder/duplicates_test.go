@@ -0,0 +1,60 @@
+package pathfinder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanDuplicates(t *testing.T) {
+	block := "def load(path):\n" +
+		"    with open(path) as f:\n" +
+		"        rows = f.readlines()\n" +
+		"    result = []\n" +
+		"    for row in rows:\n" +
+		"        result.append(row.split(\",\"))\n" +
+		"    return result\n"
+	copied := "def load(path):  # copied\n" +
+		"    with open(path)   as f:\n" +
+		"\n" +
+		"        rows = f.readlines()\n" +
+		"    result = []\n" +
+		"    for row in rows:\n" +
+		"        result.append(row.split(\";\"))\n" +
+		"    return result\n"
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"a/util.py":  "import os\n\n" + block + "\nprint(os.sep)\n",
+		"b/io.py":    "import sys\nimport json\n\n\n" + copied,
+		"c/x.go":     "package c\n\nfunc A() {\n\ta := 1\n\tb := 2\n\tc := 3\n\td := 4\n\t_ = a + b + c + d\n}\n",
+		"d/x.go":     "package c\n\n// A is a copy.\nfunc A() {\n\ta := 1\n\tb := 2\n\tc := 3\n\td := 4\n\t_ = a + b + c + d\n}\n",
+		"e/small.go": "package c\n",
+		"f/small.go": "package c\n",
+	})
+
+	report, err := Scan(Config{PathFlag: root, RecursiveFlag: true, MaxDepthFlag: -1, DuplicatesFlag: true})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	got := report.DuplicateMetrics
+	if got.Files != 6 || got.Lines != 34 || got.DuplicatedLines != 14 || got.Partial {
+		t.Fatalf("DuplicateMetrics = %d files, %d lines, %d duplicated, want 6, 34 and 14", got.Files, got.Lines, got.DuplicatedLines)
+	}
+
+	wantFiles := []DuplicateFileGroup{{Lines: 7, Paths: []string{"c/x.go", "d/x.go"}}}
+	if !reflect.DeepEqual(got.DuplicateFiles, wantFiles) {
+		t.Fatalf("DuplicateFiles = %+v, want %+v", got.DuplicateFiles, wantFiles)
+	}
+
+	wantBlocks := []DuplicateBlock{{
+		Lines: 7,
+		Locations: []DuplicateLocation{
+			{Path: "a/util.py", StartLine: 3, EndLine: 9},
+			{Path: "b/io.py", StartLine: 5, EndLine: 12},
+		},
+	}}
+	if !reflect.DeepEqual(got.Blocks, wantBlocks) {
+		t.Fatalf("Blocks = %+v, want %+v", got.Blocks, wantBlocks)
+	}
+}
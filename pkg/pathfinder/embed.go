@@ -0,0 +1,326 @@
+package pathfinder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// regionSplitter tracks the regions of a file that are written in an embedded language.
+type regionSplitter interface {
+	// next returns the embedded language the trimmed line belongs to, or nil if it belongs to the parent
+	// language. Lines opening or closing a region (e.g. "<script>" or "```go") belong to the parent.
+	next(line []byte, parent *lineCounter) *LanguageDefinition
+}
+
+func newRegionSplitter(langDef *LanguageDefinition, languages *languageRegistry) regionSplitter {
+	switch langDef.Embedded {
+	case EmbedHTML:
+		return &htmlSplitter{languages: languages}
+	case EmbedMarkdown:
+		return &markdownSplitter{languages: languages}
+	default:
+		return nil
+	}
+}
+
+// htmlComment matches comments on a single line, or a comment running past its end.
+var htmlComment = regexp.MustCompile(`<!--.*?(-->|$)`)
+
+// htmlAttribute matches the lang and type attributes of a <script> or <style> tag.
+var htmlAttribute = regexp.MustCompile(`\b(lang|type)\s*=\s*["']?([^"'\s>]+)`)
+
+// htmlSplitter splits <script> and <style> blocks out of HTML-like files. Blocks that open and close on
+// the same line are left to the parent language.
+type htmlSplitter struct {
+	languages *languageRegistry
+	closeTag  []byte              // "</script" or "</style" while inside a block
+	child     *LanguageDefinition // language of the open block, nil if it isn't known
+}
+
+func (s *htmlSplitter) next(line []byte, parent *lineCounter) *LanguageDefinition {
+	lower := bytes.ToLower(line)
+	if s.closeTag != nil {
+		if bytes.Contains(lower, s.closeTag) {
+			s.closeTag, s.child = nil, nil
+			return nil
+		}
+		return s.child
+	}
+	if parent.block != nil {
+		return nil // tags inside an HTML comment don't open anything
+	}
+	lower = htmlComment.ReplaceAll(lower, nil)
+
+	for _, tag := range []string{"script", "style"} {
+		i := bytes.Index(lower, []byte("<"+tag))
+		if i == -1 {
+			continue
+		}
+		if end := i + len(tag) + 1; end < len(lower) && lower[end] != '>' && lower[end] != ' ' && lower[end] != '\t' {
+			continue // e.g. <scripts> or <stylesheet>
+		}
+		closeTag := []byte("</" + tag)
+		if bytes.Contains(lower[i:], closeTag) {
+			return nil
+		}
+		s.closeTag = closeTag
+		s.child = s.tagLanguage(tag, lower[i:])
+		return nil
+	}
+	return nil
+}
+
+// tagLanguage picks the language of a block from the lang attribute of its lowercased tag (e.g. Vue's
+// <style lang="scss">), then its type attribute, defaulting to JavaScript for scripts and CSS for styles.
+func (s *htmlSplitter) tagLanguage(tag string, line []byte) *LanguageDefinition {
+	attributes := map[string]string{}
+	for _, match := range htmlAttribute.FindAllSubmatch(line, -1) {
+		attributes[string(match[1])] = string(match[2])
+	}
+
+	if lang := attributes["lang"]; lang != "" {
+		return s.languages.resolveLanguage(lang)
+	}
+	switch mediaType := attributes["type"]; {
+	case tag == "style" && (mediaType == "" || mediaType == "text/css"):
+		return s.languages.determineLangByName("CSS")
+	case tag == "style":
+		return nil
+	case mediaType == "", mediaType == "module", mediaType == "text/javascript", mediaType == "application/javascript", mediaType == "text/babel":
+		return s.languages.determineLangByName("JavaScript")
+	default:
+		// e.g. "text/typescript" or "application/ld+json", templates like "text/x-template" stay with the parent
+		_, subtype, _ := strings.Cut(mediaType, "/")
+		if _, suffix, ok := strings.Cut(subtype, "+"); ok {
+			subtype = suffix
+		}
+		return s.languages.resolveLanguage(subtype)
+	}
+}
+
+// markdownSplitter splits fenced code blocks out of Markdown files by their info string. Fences in an
+// unknown language are left to the parent language.
+type markdownSplitter struct {
+	languages *languageRegistry
+	fence     []byte              // opening fence (e.g. "```" or "~~~~") while inside a block
+	child     *LanguageDefinition // language of the open block, nil if it isn't known
+}
+
+func (s *markdownSplitter) next(line []byte, parent *lineCounter) *LanguageDefinition {
+	if s.fence != nil {
+		if isClosingFence(line, s.fence) {
+			s.fence, s.child = nil, nil
+			return nil
+		}
+		return s.child
+	}
+	if parent.block != nil {
+		return nil
+	}
+
+	fence, info := openingFence(line)
+	if fence == nil {
+		return nil
+	}
+	s.fence = fence
+	s.child = s.languages.resolveLanguage(info)
+	return nil
+}
+
+// openingFence returns the fence and the language of its info string (e.g. "go" for "```go" or "```{.go}").
+func openingFence(line []byte) ([]byte, string) {
+	if !bytes.HasPrefix(line, []byte("```")) && !bytes.HasPrefix(line, []byte("~~~")) {
+		return nil, ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	info := bytes.TrimSpace(line[n:])
+	if line[0] == '`' && bytes.IndexByte(info, '`') != -1 {
+		return nil, "" // inline code like ```x```, not a fence
+	}
+	if fields := strings.Fields(string(info)); len(fields) > 0 {
+		return line[:n], strings.Trim(fields[0], "{}.")
+	}
+	return line[:n], ""
+}
+
+func isClosingFence(line, fence []byte) bool {
+	if len(line) < len(fence) {
+		return false
+	}
+	for _, c := range line {
+		if c != fence[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// notebook is the part of a Jupyter notebook (.ipynb) needed to count its cells.
+type notebook struct {
+	Cells []struct {
+		CellType string          `json:"cell_type"`
+		Source   json.RawMessage `json:"source"` // either a string or a list of lines
+	} `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// countNotebook counts the cells of a Jupyter notebook instead of its JSON: code cells in the kernel
+// language and markdown cells as Markdown, all reported as children of the notebook. Raw cells are skipped.
+func countNotebook(r io.Reader, flags Config, languages *languageRegistry, langDef *LanguageDefinition) (fileCount, error) {
+	var nb notebook
+	if err := json.NewDecoder(r).Decode(&nb); err != nil {
+		return fileCount{}, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	kernel := nb.Metadata.Kernelspec.Language
+	if kernel == "" {
+		kernel = nb.Metadata.LanguageInfo.Name
+	}
+	codeLang := languages.resolveLanguage(kernel)
+	markdownLang := languages.determineLangByName("Markdown")
+
+	langMetrics := LanguageMetrics{Language: langDef.Name, Files: 1}
+	var annMetrics AnnotationMetrics
+	children := map[string]*LanguageMetrics{}
+
+	for _, cell := range nb.Cells {
+		var cellLang *LanguageDefinition
+		switch cell.CellType {
+		case "code":
+			cellLang = codeLang
+		case "markdown":
+			cellLang = markdownLang
+		}
+		if cellLang == nil {
+			continue
+		}
+
+		source, err := cellSource(cell.Source)
+		if err != nil {
+			return fileCount{}, fmt.Errorf("invalid notebook: %w", err)
+		}
+		if source == "" {
+			continue
+		}
+		if !strings.HasSuffix(source, "\n") {
+			source += "\n"
+		}
+
+		cellCount, err := countLinesInFile(strings.NewReader(source), flags, languages, cellLang)
+		if err != nil {
+			return fileCount{}, err
+		}
+		cellMetrics := cellCount.metrics
+		// code fences in markdown cells are flattened into the notebook's own children
+		for _, child := range append(cellMetrics.Children, cellMetrics) {
+			child.Files = 0
+			child.Children = nil
+			addChildMetrics(children, child)
+		}
+		annMetrics.add(cellCount.annotations)
+	}
+
+	langMetrics.Children = sortedChildren(children)
+	return fileCount{metrics: langMetrics, annotations: annMetrics}, nil
+}
+
+func cellSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var source string
+	if err := json.Unmarshal(raw, &source); err == nil {
+		return source, nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// addChildMetrics adds the counts of an embedded language to the ones stored under its name.
+func addChildMetrics(children map[string]*LanguageMetrics, metrics LanguageMetrics) {
+	child := children[metrics.Language]
+	if child == nil {
+		child = &LanguageMetrics{Language: metrics.Language}
+		children[metrics.Language] = child
+	}
+	child.add(metrics)
+}
+
+func sortedChildren(children map[string]*LanguageMetrics) []LanguageMetrics {
+	if len(children) == 0 {
+		return nil
+	}
+	sorted := make([]LanguageMetrics, 0, len(children))
+	for _, child := range children {
+		sorted = append(sorted, *child)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Language < sorted[j].Language
+	})
+	return sorted
+}
+
+// add sums the counts of other into m, merging embedded languages by name.
+func (m *LanguageMetrics) add(other LanguageMetrics) {
+	m.Files += other.Files
+	m.addLines(other)
+	for _, otherChild := range other.Children {
+		found := false
+		for i := range m.Children {
+			if m.Children[i].Language == otherChild.Language {
+				m.Children[i].add(otherChild)
+				found = true
+				break
+			}
+		}
+		if !found {
+			m.Children = append(m.Children, LanguageMetrics{Language: otherChild.Language})
+			m.Children[len(m.Children)-1].add(otherChild)
+			sort.Slice(m.Children, func(i, j int) bool {
+				return m.Children[i].Language < m.Children[j].Language
+			})
+		}
+	}
+}
+
+// addLines sums the counts of other into m, except Files and Children.
+func (m *LanguageMetrics) addLines(other LanguageMetrics) {
+	m.Code += other.Code
+	m.Comments += other.Comments
+	m.Blanks += other.Blanks
+	m.Docs += other.Docs
+	m.DocComments += other.DocComments
+	m.Mixed += other.Mixed
+	m.Lines += other.Lines
+	m.Complexity += other.Complexity
+	m.MaxNesting = max(m.MaxNesting, other.MaxNesting)
+	m.LongestLine = max(m.LongestLine, other.LongestLine)
+}
+
+// Total returns the metrics with the lines of every embedded language added in, and no Children.
+func (m LanguageMetrics) Total() LanguageMetrics {
+	total := m
+	total.Children = nil
+	for _, child := range m.Children {
+		total.addLines(child)
+	}
+	return total
+}
@@ -0,0 +1,111 @@
+package pathfinder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountEmbeddedLanguages(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		code     int
+		comments int
+		children map[string]int // code lines per embedded language
+	}{
+		{
+			name:     "html script and style",
+			language: "HTML",
+			content:  "<html>\n<!-- <script> -->\n<script type=\"module\">\n// setup\nlet x = 1;\n</script>\n<style>\nbody { margin: 0; }\n</style>\n<script src=\"app.js\"></script>\n</html>\n",
+			code:     7,
+			comments: 1,
+			children: map[string]int{"JavaScript": 1, "CSS": 1},
+		},
+		{
+			name:     "vue lang attributes",
+			language: "Vue",
+			content:  "<template>\n<div/>\n</template>\n<script setup lang=\"ts\">\nconst n: number = 1;\n</script>\n<style lang=\"scss\" scoped>\n.a { .b { color: red; } }\n</style>\n",
+			code:     7,
+			children: map[string]int{"TypeScript": 1, "SCSS": 1},
+		},
+		{
+			name:     "script template stays html",
+			language: "HTML",
+			content:  "<script type=\"text/x-template\">\n<div></div>\n</script>\n",
+			code:     3,
+		},
+		{
+			name:     "markdown fences",
+			language: "Markdown",
+			content:  "# Title\n\n```go\n// comment\nfunc main() {}\n```\n\n~~~~{.python}\nprint(1)\n~~~~\n\n```\nplain\n```\n",
+			code:     8,
+			children: map[string]int{"Go": 1, "Python": 1},
+		},
+		{
+			name:     "notebook cells",
+			language: "Jupyter Notebook",
+			content: `{"metadata": {"kernelspec": {"language": "python"}}, "cells": [
+				{"cell_type": "markdown", "source": ["# Analysis\n", "\n", "` + "```sh\\n" + `ls\n` + "```\\n" + `"]},
+				{"cell_type": "code", "source": "import os\n# TODO: clean up\nprint(os.getcwd())"},
+				{"cell_type": "raw", "source": "ignored"}
+			]}`,
+			children: map[string]int{"Markdown": 3, "Python": 2, "Shell": 1},
+		},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			langDef := languages.determineLangByName(tt.language)
+			count, err := countLinesInFile(strings.NewReader(tt.content), Config{BufferSizeFlag: 4096}, languages, langDef)
+			if err != nil {
+				t.Fatalf("countLinesInFile() error = %v", err)
+			}
+			got := count.metrics
+			if got.Code != tt.code || got.Comments != tt.comments {
+				t.Fatalf("countLinesInFile() = %d code, %d comments, want %d, %d", got.Code, got.Comments, tt.code, tt.comments)
+			}
+
+			children := map[string]int{}
+			for _, child := range got.Children {
+				children[child.Language] = child.Code
+			}
+			if len(children) != len(tt.children) {
+				t.Fatalf("Children = %v, want %v", children, tt.children)
+			}
+			for language, code := range tt.children {
+				if children[language] != code {
+					t.Fatalf("Children = %v, want %v", children, tt.children)
+				}
+			}
+		})
+	}
+}
+
+func TestScanRollsUpEmbeddedLanguages(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"index.html": "<html>\n<script>\nrun();\n</script>\n</html>\n",
+		"app.js":     "run();\n",
+	})
+
+	report, err := Scan(Config{PathFlag: root})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	got := map[string]LanguageMetrics{}
+	for _, lang := range report.LanguageMetrics {
+		got[lang.Metrics.Language] = lang.Metrics
+	}
+	if js := got["JavaScript"]; js.Files != 1 || js.Code != 2 {
+		t.Fatalf("JavaScript = %+v, want 1 file and 2 code lines", js)
+	}
+	if html := got["HTML"]; html.Code != 4 || len(html.Children) != 1 || html.Children[0].Code != 1 {
+		t.Fatalf("HTML = %+v, want 4 code lines and 1 nested JavaScript line", html)
+	}
+	if report.CodebaseMetrics.TotalCode != 6 || report.CodebaseMetrics.TotalLines != 6 {
+		t.Fatalf("CodebaseMetrics = %+v, want 6 code lines", report.CodebaseMetrics)
+	}
+}
@@ -0,0 +1,115 @@
+package pathfinder
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+	"golang.org/x/text/transform"
+)
+
+// detectEncoding works out the encoding of a file from its byte order mark, or from the pattern of zero
+// bytes for UTF-16 without one. Text that isn't valid UTF-8 is taken as Windows-1252, the most common
+// legacy encoding. It returns the length of the byte order mark to skip as well.
+func detectEncoding(head []byte) (Encoding, int) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8BOM, 3
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE, 0x00, 0x00}):
+		return EncodingUTF32LE, 4
+	case bytes.HasPrefix(head, []byte{0x00, 0x00, 0xFE, 0xFF}):
+		return EncodingUTF32BE, 4
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE, 2
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE, 2
+	}
+
+	// mostly ASCII UTF-16 has a zero in every other byte: the high bytes, odd in little endian
+	if len(head) >= 4 {
+		var evenZeros, oddZeros int
+		for i := 0; i+1 < len(head); i += 2 {
+			if head[i] == 0 {
+				evenZeros++
+			}
+			if head[i+1] == 0 {
+				oddZeros++
+			}
+		}
+		pairs := len(head) / 2
+		switch {
+		case oddZeros*10 >= pairs*4 && evenZeros*10 < pairs:
+			return EncodingUTF16LE, 0
+		case evenZeros*10 >= pairs*4 && oddZeros*10 < pairs:
+			return EncodingUTF16BE, 0
+		}
+	}
+
+	// the head can end in the middle of a multi-byte character
+	valid := head
+	for i := 0; i < utf8.UTFMax-1 && len(valid) > 0 && !utf8.Valid(valid); i++ {
+		valid = valid[:len(valid)-1]
+	}
+	if !utf8.Valid(valid) && bytes.IndexByte(head, 0) == -1 {
+		return EncodingWindows1252, 0
+	}
+	return EncodingUTF8, 0
+}
+
+// decodeReader detects the encoding of br from its head (see peekHead) and returns a reader of its content
+// as UTF-8, without a byte order mark. UTF-8 content is returned as br itself, so it isn't buffered twice.
+func decodeReader(br *bufio.Reader, head []byte) (io.Reader, Encoding, error) {
+	enc, bomLength := detectEncoding(head)
+	if _, err := br.Discard(bomLength); err != nil {
+		return nil, "", err
+	}
+
+	decoder := enc.decoder()
+	if decoder == nil {
+		return br, enc, nil
+	}
+	return transform.NewReader(br, decoder.NewDecoder()), enc, nil
+}
+
+// decodeHead decodes the start of a file for content sniffing.
+func decodeHead(head []byte) []byte {
+	enc, bomLength := detectEncoding(head)
+	return decodeBytes(head[bomLength:], enc)
+}
+
+// decodeBytes decodes part of a file in enc, such as its tail, returning it unchanged if it can't be decoded.
+func decodeBytes(b []byte, enc Encoding) []byte {
+	decoder := enc.decoder()
+	if decoder == nil {
+		return b
+	}
+
+	decoded, _, err := transform.Bytes(decoder.NewDecoder(), b)
+	if err != nil {
+		return b
+	}
+	return decoded
+}
+
+// decoder returns the decoder to UTF-8 for e, or nil if e is UTF-8 already.
+func (e Encoding) decoder() encoding.Encoding {
+	switch e {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case EncodingUTF32LE:
+		return utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM)
+	case EncodingUTF32BE:
+		return utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM)
+	case EncodingWindows1252:
+		return charmap.Windows1252
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package pathfinder
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestDecodeReader(t *testing.T) {
+	utf16le := func(s string, bom bool) []byte {
+		var b []byte
+		if bom {
+			b = append(b, 0xFF, 0xFE)
+		}
+		for _, unit := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(unit), byte(unit>>8))
+		}
+		return b
+	}
+	utf16be := func(s string) []byte {
+		var b []byte
+		for _, unit := range utf16.Encode([]rune(s)) {
+			b = append(b, byte(unit>>8), byte(unit))
+		}
+		return b
+	}
+
+	source := "// TODO: café\nint x = 1;\n\n"
+	tests := []struct {
+		name     string
+		language string
+		content  []byte
+		encoding Encoding
+		code     int
+		comments int
+		blanks   int
+		todo     string
+	}{
+		{name: "utf-8", language: "C", content: []byte(source), encoding: EncodingUTF8, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "utf-8 bom", language: "C", content: append([]byte{0xEF, 0xBB, 0xBF}, source...), encoding: EncodingUTF8BOM, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "utf-16le bom", language: "C#", content: utf16le(source, true), encoding: EncodingUTF16LE, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "utf-16le without bom", language: "C#", content: utf16le(source, false), encoding: EncodingUTF16LE, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "utf-16be without bom", language: "C", content: utf16be(source), encoding: EncodingUTF16BE, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "windows-1252", language: "C", content: []byte("// TODO: caf\xe9\nint x = 1;\n\n"), encoding: EncodingWindows1252, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "old mac line endings", language: "C", content: []byte("// TODO: café\rint x = 1;\r\r"), encoding: EncodingUTF8, code: 1, comments: 1, blanks: 1, todo: "café"},
+		{name: "mixed line endings", language: "C", content: []byte("int a;\r\nint b;\rint c;\n// TODO: café"), encoding: EncodingUTF8, code: 3, comments: 1, todo: "café"},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReaderSize(bytes.NewReader(tt.content), 4096)
+			head, err := peekHead(br)
+			if err != nil {
+				t.Fatalf("peekHead() error = %v", err)
+			}
+			r, enc, err := decodeReader(br, head)
+			if err != nil {
+				t.Fatalf("decodeReader() error = %v", err)
+			}
+			if enc != tt.encoding {
+				t.Fatalf("encoding = %q, want %q", enc, tt.encoding)
+			}
+
+			count, err := countLinesInFile(r, Config{BufferSizeFlag: 4096}, languages, languages.determineLangByName(tt.language))
+			if err != nil {
+				t.Fatalf("countLinesInFile() error = %v", err)
+			}
+			got, ann := count.metrics, count.annotations
+			if got.Code != tt.code || got.Comments != tt.comments || got.Blanks != tt.blanks {
+				t.Fatalf("countLinesInFile() = %d code, %d comments, %d blanks, want %d, %d, %d",
+					got.Code, got.Comments, got.Blanks, tt.code, tt.comments, tt.blanks)
+			}
+			if len(ann.Annotations) != 1 || ann.Annotations[0].Text != tt.todo {
+				t.Fatalf("Annotations = %+v, want one TODO %q", ann.Annotations, tt.todo)
+			}
+		})
+	}
+}
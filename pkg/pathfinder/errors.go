@@ -0,0 +1,34 @@
+package pathfinder
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+)
+
+// newScanError classifies an error hit while reading path, which is reported relative to rootPath.
+func newScanError(rootPath, path string, err error) ScanError {
+	relPath, relErr := filepath.Rel(rootPath, path)
+	if relErr != nil {
+		relPath = path
+	}
+
+	kind := ErrorKindRead
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		kind = ErrorKindPermission
+	case errors.Is(err, fs.ErrNotExist):
+		kind = ErrorKindVanished // listed by the walker but deleted before a worker could open it
+	}
+
+	return ScanError{
+		Path:    relPath,
+		Kind:    kind,
+		Message: err.Error(),
+	}
+}
+
+// Error implements the error interface so a ScanError can be returned directly in fail-fast mode.
+func (e ScanError) Error() string {
+	return e.Path + ": " + string(e.Kind) + ": " + e.Message
+}
@@ -0,0 +1,80 @@
+package pathfinder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// pathFilter applies the built-in exclusions and the user supplied include/exclude globs to walked paths.
+type pathFilter struct {
+	defaults bool
+	vendored bool // walk vendored directories despite the defaults
+	excludes []string
+	includes []string
+}
+
+func newPathFilter(flags Config) *pathFilter {
+	return &pathFilter{
+		defaults: !flags.NoDefaultExcludesFlag,
+		vendored: flags.IncludeVendoredFlag,
+		excludes: normalizeGlobs(flags.ExcludeFlag),
+		includes: normalizeGlobs(flags.IncludeFlag),
+	}
+}
+
+func validateGlobs(flag string, patterns []string) error {
+	for _, pattern := range patterns {
+		if !doublestar.ValidatePattern(filepath.ToSlash(pattern)) {
+			return fmt.Errorf("invalid %s pattern %q", flag, pattern)
+		}
+	}
+	return nil
+}
+
+// normalizeGlobs makes patterns without a slash match the name at any depth, like .gitignore does.
+func normalizeGlobs(patterns []string) []string {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		pattern = strings.TrimSuffix(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		normalized = append(normalized, pattern)
+	}
+	return normalized
+}
+
+// skipDir reports whether a directory (rel is its slash path relative to the scan root) should not be walked.
+func (f *pathFilter) skipDir(rel, name string) bool {
+	if f.defaults && excludeDir(name) && !(f.vendored && isVendoredDir(name)) {
+		return true
+	}
+	return matchAnyGlob(f.excludes, rel)
+}
+
+// skipFile reports whether a file (rel is its slash path relative to the scan root) should not be scanned.
+func (f *pathFilter) skipFile(rel, name string) bool {
+	if f.defaults && excludeFile(name) {
+		return true
+	}
+	if matchAnyGlob(f.excludes, rel) {
+		return true
+	}
+	return len(f.includes) > 0 && !matchAnyGlob(f.includes, rel)
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package pathfinder
+
+import (
+	"path"
+	"testing"
+)
+
+func TestPathFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		rel    string
+		isDir  bool
+		want   bool
+	}{
+		{name: "default dir excluded", rel: "web/node_modules", isDir: true, want: true},
+		{name: "default file excluded", rel: "go.sum", want: true},
+		{name: "vendored included", config: Config{IncludeVendoredFlag: true}, rel: "web/node_modules", isDir: true, want: false},
+		{name: "defaults replaced", config: Config{NoDefaultExcludesFlag: true}, rel: "vendor", isDir: true, want: false},
+		{name: "recursive glob", config: Config{ExcludeFlag: []string{"**/*_generated.go"}}, rel: "a/b/api_generated.go", want: true},
+		{name: "name glob at any depth", config: Config{ExcludeFlag: []string{"*.min.js"}}, rel: "static/js/app.min.js", want: true},
+		{name: "directory glob", config: Config{ExcludeFlag: []string{"docs/**"}}, rel: "docs", isDir: true, want: true},
+		{name: "anchored glob", config: Config{ExcludeFlag: []string{"docs/**"}}, rel: "src/docs", isDir: true, want: false},
+		{name: "include match", config: Config{IncludeFlag: []string{"src/**"}}, rel: "src/main.go", want: false},
+		{name: "include miss", config: Config{IncludeFlag: []string{"src/**"}}, rel: "scripts/run.py", want: true},
+		{name: "include ignored for dirs", config: Config{IncludeFlag: []string{"src/**"}}, rel: "scripts", isDir: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newPathFilter(tt.config)
+			name := path.Base(tt.rel)
+
+			var got bool
+			if tt.isDir {
+				got = filter.skipDir(tt.rel, name)
+			} else {
+				got = filter.skipFile(tt.rel, name)
+			}
+			if got != tt.want {
+				t.Fatalf("skip(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package pathfinder
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// longestFunctionsLimit is how many functions CodebaseReport.LongestFunctions keeps.
+const longestFunctionsLimit = 25
+
+// notFunctionNames are control flow keywords whose statements look like declarations to a line pattern
+// (e.g. "if (x) {" for a JavaScript method), checked against the name and the first word of a match.
+var notFunctionNames = map[string]struct{}{
+	"if": {}, "elif": {}, "else": {}, "for": {}, "foreach": {}, "while": {}, "do": {}, "switch": {}, "case": {},
+	"catch": {}, "try": {}, "return": {}, "throw": {}, "new": {}, "with": {}, "await": {}, "yield": {},
+	"typeof": {}, "sizeof": {}, "synchronized": {},
+}
+
+// functionPatterns caches compiled FunctionRules patterns, so they aren't compiled for every file.
+var functionPatterns sync.Map
+
+func functionPattern(pattern string) *regexp.Regexp {
+	if re, ok := functionPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil // custom patterns are validated up front
+	}
+	functionPatterns.Store(pattern, re)
+	return re
+}
+
+// openFunction is a function whose declaration has been seen but whose body hasn't ended yet.
+type openFunction struct {
+	FunctionMetrics
+	header   []byte // code from the function name up to its body, for counting parameters
+	parens   int    // depth of parentheses in header, above 0 while the parameter list continues
+	base     int    // brace depth the function was declared at, or its indentation for indented bodies
+	lastLine int    // last line with code in an indented body
+}
+
+// functionTracker finds functions and their line spans in one language of a file, from the code of each
+// line (see lineCounter.code). Brace bodies end with the brace matching their first one; indented bodies
+// end before the next code line indented no deeper than the declaration.
+type functionTracker struct {
+	patterns []*regexp.Regexp
+	indent   bool
+
+	depth       int           // brace depth
+	pending     *openFunction // declared, but its opening brace hasn't been seen yet
+	pendingDone bool          // pending's parameter list closed on an earlier line, so its body must open on this one
+	open        []*openFunction
+	functions   []FunctionMetrics
+}
+
+func newFunctionTracker(langDef *LanguageDefinition) *functionTracker {
+	if langDef.Functions == nil {
+		return nil
+	}
+	t := &functionTracker{indent: langDef.Functions.Indent}
+	for _, pattern := range langDef.Functions.Patterns {
+		if re := functionPattern(pattern); re != nil {
+			t.patterns = append(t.patterns, re)
+		}
+	}
+	return t
+}
+
+// declaration returns the function declared by code, with the offset its name ends at, if any.
+func (t *functionTracker) declaration(code []byte) (string, int, bool) {
+	for _, re := range t.patterns {
+		match := re.FindSubmatchIndex(code)
+		if match == nil {
+			continue
+		}
+		nameGroup := re.SubexpIndex("name")
+		if nameGroup == -1 || match[2*nameGroup] == -1 {
+			continue
+		}
+		name := string(code[match[2*nameGroup]:match[2*nameGroup+1]])
+		first := code
+		if i := bytes.IndexAny(code, " \t("); i != -1 {
+			first = code[:i]
+		}
+		if _, ok := notFunctionNames[name]; ok {
+			continue
+		}
+		if _, ok := notFunctionNames[string(first)]; ok {
+			continue
+		}
+		return name, match[2*nameGroup+1], true
+	}
+	return "", 0, false
+}
+
+// track adds a line: its number, the physical line (for indentation) and the code on it.
+func (t *functionTracker) track(lineNo int, physical, code []byte) {
+	code = bytes.TrimSpace(code)
+	if len(code) == 0 {
+		return
+	}
+	if t.indent {
+		t.trackIndented(lineNo, physical, code)
+	} else {
+		t.trackBraces(lineNo, code)
+	}
+}
+
+func (t *functionTracker) trackBraces(lineNo int, code []byte) {
+	// a declaration whose parameter list already closed only continues on a line opening its body (Allman style)
+	if t.pending != nil && t.pendingDone && code[0] != '{' {
+		t.pending = nil
+	}
+
+	start := 0
+	if name, end, ok := t.declaration(code); ok {
+		t.pending = &openFunction{FunctionMetrics: FunctionMetrics{Name: name, StartLine: lineNo}}
+		start = end
+		for _, b := range code[:end] {
+			switch b {
+			case '{':
+				t.depth++
+			case '}':
+				t.closeBrace(lineNo)
+			}
+		}
+	}
+
+	for i := start; i < len(code); i++ {
+		b := code[i]
+		if fn := t.pending; fn != nil {
+			// an empty brace pair in the signature is a type (e.g. "struct{}"), unless it ends the line
+			if b == '{' && i+2 < len(code) && code[i+1] == '}' {
+				fn.header = append(fn.header, code[i:i+2]...)
+				i++
+				continue
+			}
+			fn.header = append(fn.header, b)
+			switch {
+			case b == '(':
+				fn.parens++
+			case b == ')':
+				fn.parens--
+			case b == ';' && fn.parens <= 0:
+				t.pending = nil // declared without a body, e.g. an interface or abstract method
+				continue
+			case b == '{' && fn.parens <= 0:
+				fn.Params = countParams(fn.header)
+				fn.base = t.depth
+				t.open = append(t.open, fn)
+				t.pending = nil
+			}
+		}
+		switch b {
+		case '{':
+			t.depth++
+		case '}':
+			t.closeBrace(lineNo)
+		}
+	}
+
+	if t.pending != nil {
+		t.pendingDone = t.pending.parens <= 0
+	}
+}
+
+func (t *functionTracker) closeBrace(lineNo int) {
+	t.depth = max(t.depth-1, 0)
+	if n := len(t.open); n > 0 && t.open[n-1].base == t.depth {
+		t.finish(t.open[n-1], lineNo)
+		t.open = t.open[:n-1]
+	}
+}
+
+func (t *functionTracker) trackIndented(lineNo int, physical, code []byte) {
+	// a parameter list spanning several lines isn't indented like the body
+	if n := len(t.open); n > 0 && t.open[n-1].parens > 0 {
+		fn := t.open[n-1]
+		t.addHeader(fn, code)
+		fn.lastLine = lineNo
+		return
+	}
+
+	indent := 0
+	for _, b := range physical {
+		if b == ' ' {
+			indent++
+		} else if b == '\t' {
+			indent += tabWidth
+		} else {
+			break
+		}
+	}
+
+	for n := len(t.open); n > 0 && indent <= t.open[n-1].base; n = len(t.open) {
+		t.finish(t.open[n-1], t.open[n-1].lastLine)
+		t.open = t.open[:n-1]
+	}
+	for _, fn := range t.open {
+		fn.lastLine = lineNo
+	}
+
+	if name, end, ok := t.declaration(code); ok {
+		fn := &openFunction{FunctionMetrics: FunctionMetrics{Name: name, StartLine: lineNo}, base: indent, lastLine: lineNo}
+		t.addHeader(fn, code[end:])
+		t.open = append(t.open, fn)
+	}
+}
+
+// addHeader adds code to an indented function's header, counting its parameters once the list closes.
+func (t *functionTracker) addHeader(fn *openFunction, code []byte) {
+	for _, b := range code {
+		if fn.parens == 0 && len(fn.header) > 0 && bytes.IndexByte(fn.header, '(') != -1 {
+			break // the parameter list is complete
+		}
+		fn.header = append(fn.header, b)
+		switch b {
+		case '(':
+			fn.parens++
+		case ')':
+			fn.parens--
+		}
+	}
+	if fn.parens == 0 {
+		fn.Params = countParams(fn.header)
+	}
+}
+
+func (t *functionTracker) finish(fn *openFunction, endLine int) {
+	fn.EndLine = endLine
+	fn.Lines = endLine - fn.StartLine + 1
+	t.functions = append(t.functions, fn.FunctionMetrics)
+}
+
+// close ends the functions still open at the end of the file on its last line, and returns every
+// function found in the order they start.
+func (t *functionTracker) close(lastLine int) []FunctionMetrics {
+	for i := len(t.open) - 1; i >= 0; i-- {
+		end := lastLine
+		if t.indent {
+			end = t.open[i].lastLine
+		}
+		t.finish(t.open[i], end)
+	}
+	t.open = nil
+	sort.SliceStable(t.functions, func(i, j int) bool {
+		return t.functions[i].StartLine < t.functions[j].StartLine
+	})
+	return t.functions
+}
+
+// countParams counts the parameters in the first parenthesized list of header, split on top level commas.
+func countParams(header []byte) int {
+	open := bytes.IndexByte(header, '(')
+	if open == -1 {
+		return 0
+	}
+
+	params, parens, depth := 0, 0, 0
+	nonEmpty := false
+	for _, b := range header[open+1:] {
+		switch b {
+		case '(':
+			parens++
+		case ')':
+			if parens == 0 {
+				if nonEmpty {
+					params++
+				}
+				return params
+			}
+			parens--
+		case '[', '{', '<':
+			depth++
+		case ']', '}', '>':
+			depth = max(depth-1, 0) // a '>' can be part of "=>" or a comparison in a default value
+		case ',':
+			if parens == 0 && depth == 0 {
+				if nonEmpty {
+					params++
+				}
+				nonEmpty = false
+				continue
+			}
+		}
+		if b != ' ' && b != '\t' {
+			nonEmpty = true
+		}
+	}
+	if nonEmpty {
+		params++
+	}
+	return params
+}
+
+// longestFunctions returns the longest functions of every file, longest first.
+func longestFunctions(files []FileMetricsReport, limit int) []FunctionMetrics {
+	var functions []FunctionMetrics
+	for _, file := range files {
+		functions = append(functions, file.Functions...)
+	}
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functions[i].Lines > functions[j].Lines
+	})
+	if len(functions) > limit {
+		functions = functions[:limit]
+	}
+	return functions
+}
@@ -0,0 +1,91 @@
+package pathfinder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionMetrics(t *testing.T) {
+	fn := func(name string, start, end, params int) FunctionMetrics {
+		return FunctionMetrics{Name: name, StartLine: start, EndLine: end, Lines: end - start + 1, Params: params}
+	}
+
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		want     []FunctionMetrics
+	}{
+		{
+			name:     "go functions and methods",
+			language: "Go",
+			content: "package p\n\n" +
+				"// func fake() {}\n" +
+				"func (s *S) Area() float64 { return s.x * s.y }\n\n" +
+				"func New(a, b int,\n\tc string) *S {\n\tf := func() {\n\t}\n\tif a > b {\n\t\treturn nil\n\t}\n\treturn &S{s: \"}\"}\n}\n" +
+				"func set() map[string]struct{} {\n\treturn nil\n}\n",
+			want: []FunctionMetrics{fn("Area", 4, 4, 0), fn("New", 6, 14, 3), fn("set", 15, 17, 0)},
+		},
+		{
+			name:     "python indentation",
+			language: "Python",
+			content: "class A:\n" +
+				"    def method(self, x,\n               y=1):\n        \"\"\"Docs.\n\ndef not_a_function():\n        \"\"\"\n        def inner():\n            pass\n        return x\n\n" +
+				"    # trailing comment\n\n" +
+				"def top(): return 1\n",
+			want: []FunctionMetrics{fn("method", 2, 10, 3), fn("inner", 8, 9, 0), fn("top", 14, 14, 0)},
+		},
+		{
+			name:     "javascript declarations, arrows and methods",
+			language: "JavaScript",
+			content: "export async function load(url, opts) {\n  if (url) {\n    return fetch(url)\n  }\n}\n" +
+				"const add = (a, b) => {\n  return a + b\n}\n" +
+				"class C {\n  static get(key) {\n    return key\n  }\n}\n" +
+				"describe('x', () => {\n})\n",
+			want: []FunctionMetrics{fn("load", 1, 5, 2), fn("add", 6, 8, 2), fn("get", 10, 12, 1)},
+		},
+		{
+			name:     "typescript class field arrows",
+			language: "TypeScript",
+			content: "class View {\n" +
+				"  handle = (e) => {\n    this.emit(e)\n  }\n" +
+				"  private onKey = async (e: KeyboardEvent): Promise<void> => {\n    await this.save()\n  }\n" +
+				"  count = 0\n}\n",
+			want: []FunctionMetrics{fn("handle", 2, 4, 1), fn("onKey", 5, 7, 1)},
+		},
+		{
+			name:     "java methods and interfaces",
+			language: "Java",
+			content: "public class Main {\n" +
+				"    public static void main(String[] args)\n    {\n        run(args);\n    }\n" +
+				"    abstract int size();\n" +
+				"    private Map<String, List<Integer>> index(List<String> words, int min) {\n        if (min > 0) {\n        }\n        return null;\n    }\n}\n",
+			want: []FunctionMetrics{fn("main", 2, 5, 1), fn("index", 7, 11, 2)},
+		},
+		{
+			name:     "java one-line methods and constructors",
+			language: "Java",
+			content: "class D {\n" +
+				"    D(int a) { this.a = a; }\n" +
+				"    public int size() { return size; }\n" +
+				"    void load(String path) throws IOException {\n        read(path);\n    }\n" +
+				"    int x = compute(1);\n}\n",
+			want: []FunctionMetrics{fn("D", 2, 2, 1), fn("size", 3, 3, 0), fn("load", 4, 6, 1)},
+		},
+	}
+
+	languages := newLanguageRegistry(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{BufferSizeFlag: 4096, FunctionMetricsFlag: true}
+			count, err := countLinesInFile(strings.NewReader(tt.content), config, languages, languages.determineLangByName(tt.language))
+			if err != nil {
+				t.Fatalf("countLinesInFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(count.functions, tt.want) {
+				t.Fatalf("functions = %+v, want %+v", count.functions, tt.want)
+			}
+		})
+	}
+}
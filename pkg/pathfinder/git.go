@@ -0,0 +1,177 @@
+package pathfinder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// markers used to split `git log` output into commits and header fields
+const (
+	gitCommitMarker = "\x1e"
+	gitFieldMarker  = "\x1f"
+)
+
+// maxGitHotspots caps how many of the most frequently changed files are kept in GitMetrics.
+const maxGitHotspots = 10
+
+type gitHistory struct {
+	metrics     GitMetrics
+	fileCommits map[string]int // commits per file, keyed by slash path relative to the scan root
+}
+
+func scanGitHistory(ctx context.Context, rootPath string) (gitHistory, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return gitHistory{}, errors.New("--git flag requires a local git installation")
+	}
+
+	topLevel, err := runGit(ctx, rootPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return gitHistory{}, fmt.Errorf("%s is not inside a git repository", rootPath)
+	}
+	repoRoot := strings.TrimSpace(string(topLevel))
+
+	// limit history to the scanned path so sub-directory scans only report their own churn
+	out, err := runGit(ctx, rootPath, "log",
+		"--no-merges",
+		"--no-renames",
+		"--name-only",
+		"--format="+gitCommitMarker+"%H"+gitFieldMarker+"%aN"+gitFieldMarker+"%aE"+gitFieldMarker+"%at",
+		"--", ".",
+	)
+	if err != nil {
+		// an empty repository has no HEAD yet, which is not an error for our purposes
+		if _, headErr := runGit(ctx, rootPath, "rev-parse", "--verify", "HEAD"); headErr != nil {
+			return gitHistory{fileCommits: map[string]int{}}, nil
+		}
+		return gitHistory{}, err
+	}
+
+	return parseGitLog(out, repoRoot, rootPath)
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir, "-c", "core.quotepath=off"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+func parseGitLog(out []byte, repoRoot, rootPath string) (gitHistory, error) {
+	history := gitHistory{fileCommits: map[string]int{}}
+	contributors := map[string]*GitContributor{}
+
+	// git reports paths relative to the repository root, but the report uses paths relative to the scan root
+	if resolved, err := filepath.EvalSymlinks(rootPath); err == nil {
+		rootPath = resolved
+	}
+	prefix, err := filepath.Rel(repoRoot, rootPath)
+	if err != nil {
+		return gitHistory{}, err
+	}
+	prefix = filepath.ToSlash(prefix)
+	if prefix == "." {
+		prefix = ""
+	} else {
+		prefix += "/"
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, gitCommitMarker) {
+			path := strings.TrimPrefix(line, prefix)
+			history.fileCommits[path]++
+			continue
+		}
+
+		fields := strings.Split(strings.TrimPrefix(line, gitCommitMarker), gitFieldMarker)
+		if len(fields) != 4 {
+			return gitHistory{}, fmt.Errorf("unexpected git log output: %q", line)
+		}
+
+		unix, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return gitHistory{}, fmt.Errorf("invalid commit timestamp %q: %w", fields[3], err)
+		}
+		date := time.Unix(unix, 0).UTC()
+
+		history.metrics.TotalCommits++
+		if history.metrics.FirstCommit.IsZero() || date.Before(history.metrics.FirstCommit) {
+			history.metrics.FirstCommit = date
+		}
+		if date.After(history.metrics.LastCommit) {
+			history.metrics.LastCommit = date
+		}
+
+		// contributors are identified by email so name spelling changes don't split them
+		key := strings.ToLower(fields[2])
+		contributor := contributors[key]
+		if contributor == nil {
+			contributor = &GitContributor{Name: fields[1], Email: fields[2]}
+			contributors[key] = contributor
+		}
+		contributor.Commits++
+	}
+	if err := scanner.Err(); err != nil {
+		return gitHistory{}, err
+	}
+
+	history.metrics.Contributors = make([]GitContributor, 0, len(contributors))
+	for _, contributor := range contributors {
+		history.metrics.Contributors = append(history.metrics.Contributors, *contributor)
+	}
+	sort.Slice(history.metrics.Contributors, func(i, j int) bool {
+		if history.metrics.Contributors[i].Commits != history.metrics.Contributors[j].Commits {
+			return history.metrics.Contributors[i].Commits > history.metrics.Contributors[j].Commits
+		}
+		return history.metrics.Contributors[i].Name < history.metrics.Contributors[j].Name
+	})
+	history.metrics.TotalContributors = len(history.metrics.Contributors)
+
+	return history, nil
+}
+
+// applyGitHistory joins per-file commit counts onto the scanned files and collects the churn hotspots.
+func applyGitHistory(history gitHistory, files []FileMetricsReport) GitMetrics {
+	metrics := history.metrics
+	metrics.Hotspots = make([]FileMetricsReport, 0)
+
+	for i := range files {
+		files[i].Commits = history.fileCommits[filepath.ToSlash(files[i].Path)]
+		if files[i].Commits > 0 {
+			metrics.Hotspots = append(metrics.Hotspots, files[i])
+		}
+	}
+
+	sort.SliceStable(metrics.Hotspots, func(i, j int) bool {
+		return metrics.Hotspots[i].Commits > metrics.Hotspots[j].Commits
+	})
+	if len(metrics.Hotspots) > maxGitHotspots {
+		metrics.Hotspots = metrics.Hotspots[:maxGitHotspots]
+	}
+
+	return metrics
+}
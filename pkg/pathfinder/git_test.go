@@ -0,0 +1,89 @@
+package pathfinder
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGitLog(t *testing.T) {
+	log := strings.Join([]string{
+		gitCommitMarker + "c3" + gitFieldMarker + "Ada" + gitFieldMarker + "ada@example.com" + gitFieldMarker + "1700000200",
+		"",
+		"sub/main.go",
+		"sub/README.md",
+		gitCommitMarker + "c2" + gitFieldMarker + "Grace" + gitFieldMarker + "grace@example.com" + gitFieldMarker + "1700000100",
+		"",
+		"sub/main.go",
+		gitCommitMarker + "c1" + gitFieldMarker + "ada" + gitFieldMarker + "ADA@example.com" + gitFieldMarker + "1700000000",
+		"",
+		"sub/main.go",
+	}, "\n")
+
+	repoRoot := t.TempDir()
+	history, err := parseGitLog([]byte(log), repoRoot, filepath.Join(repoRoot, "sub"))
+	if err != nil {
+		t.Fatalf("parseGitLog() error = %v", err)
+	}
+
+	if history.metrics.TotalCommits != 3 {
+		t.Fatalf("TotalCommits = %d, want 3", history.metrics.TotalCommits)
+	}
+	if history.metrics.TotalContributors != 2 {
+		t.Fatalf("TotalContributors = %d, want 2", history.metrics.TotalContributors)
+	}
+	if top := history.metrics.Contributors[0]; top.Name != "Ada" || top.Commits != 2 {
+		t.Fatalf("top contributor = %+v, want Ada with 2 commits", top)
+	}
+	if got := history.metrics.FirstCommit.Unix(); got != 1700000000 {
+		t.Fatalf("FirstCommit = %d, want 1700000000", got)
+	}
+	if got := history.metrics.LastCommit.Unix(); got != 1700000200 {
+		t.Fatalf("LastCommit = %d, want 1700000200", got)
+	}
+
+	files := []FileMetricsReport{{Path: "README.md"}, {Path: "main.go"}, {Path: "new.go"}}
+	metrics := applyGitHistory(history, files)
+
+	if files[1].Commits != 3 || files[0].Commits != 1 || files[2].Commits != 0 {
+		t.Fatalf("per-file commits = %d/%d/%d, want 1/3/0", files[0].Commits, files[1].Commits, files[2].Commits)
+	}
+	if len(metrics.Hotspots) != 2 || metrics.Hotspots[0].Path != "main.go" {
+		t.Fatalf("Hotspots = %+v, want main.go first of 2", metrics.Hotspots)
+	}
+}
+
+func TestParseGitBlame(t *testing.T) {
+	out := strings.Join([]string{
+		"a1b2c3 10 3 1",
+		"author Ada",
+		"author-mail <ada@example.com>",
+		"author-time 1700000000",
+		"summary add retries",
+		"filename main.go",
+		"\t// TODO: retry",
+		uncommittedHash + " 7 7 1",
+		"author Not Committed Yet",
+		"author-time 1800000000",
+		"filename main.go",
+		"\t// FIXME: wip",
+	}, "\n")
+
+	blamed, err := parseGitBlame([]byte(out))
+	if err != nil {
+		t.Fatalf("parseGitBlame() error = %v", err)
+	}
+	if got := blamed[3]; got.commit != "a1b2c3" || got.author != "Ada" || !got.date.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("line 3 = %+v, want Ada's a1b2c3", got)
+	}
+	if got := blamed[7]; got.commit != uncommittedHash {
+		t.Fatalf("line 7 = %+v, want the uncommitted hash", got)
+	}
+
+	annotations := []Annotation{{Path: "new.go"}, {Path: "b.go", Date: time.Unix(200, 0)}, {Path: "a.go", Date: time.Unix(100, 0)}}
+	sortByAge(annotations)
+	if annotations[0].Path != "a.go" || annotations[2].Path != "new.go" {
+		t.Fatalf("sortByAge() = %+v, want oldest first and unblamed last", annotations)
+	}
+}
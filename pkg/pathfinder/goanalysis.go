@@ -0,0 +1,192 @@
+package pathfinder
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// goFileMetrics is what analyzeGoFile finds in one Go file, before it's added to GoMetrics.
+type goFileMetrics struct {
+	pkg       string // package name
+	metrics   GoMetrics
+	parseFail bool
+}
+
+// analyzeGoFile parses a Go file with go/parser and counts its declarations and functions.
+// Files that don't parse (e.g. testdata with deliberate syntax errors) are only reported as such.
+func analyzeGoFile(path string) goFileMetrics {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return goFileMetrics{parseFail: true}
+	}
+
+	result := goFileMetrics{pkg: file.Name.Name}
+	metrics := &result.metrics
+	metrics.Files = 1
+	isTestFile := strings.HasSuffix(path, "_test.go")
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				metrics.Types++
+				if _, ok := spec.(*ast.TypeSpec).Type.(*ast.InterfaceType); ok {
+					metrics.Interfaces++
+				}
+			}
+
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			switch {
+			case decl.Recv != nil:
+				metrics.Methods++
+				name = receiverName(decl.Recv) + "." + name
+			case decl.Name.IsExported():
+				metrics.ExportedFuncs++
+			default:
+				metrics.UnexportedFuncs++
+			}
+			if decl.Recv == nil && isTestFile {
+				switch {
+				case isGoTestName(decl.Name.Name, "Test"):
+					metrics.TestFuncs++
+				case isGoTestName(decl.Name.Name, "Benchmark"):
+					metrics.Benchmarks++
+				}
+			}
+
+			metrics.Functions = append(metrics.Functions, GoFunctionMetrics{
+				Name:       name,
+				Line:       fset.Position(decl.Pos()).Line,
+				Lines:      fset.Position(decl.End()).Line - fset.Position(decl.Pos()).Line + 1,
+				Complexity: goComplexity(decl.Body),
+				Exported:   decl.Name.IsExported(),
+			})
+		}
+	}
+	return result
+}
+
+// receiverName returns how a method's receiver is written in its name, e.g. "(*Scanner)" or "Scanner".
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, pointer = star.X, true
+	}
+	// generic receivers, e.g. List[T]
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+	case *ast.IndexListExpr:
+		expr = index.X
+	}
+
+	name := "?"
+	if ident, ok := expr.(*ast.Ident); ok {
+		name = ident.Name
+	}
+	if pointer {
+		return "(*" + name + ")"
+	}
+	return name
+}
+
+// isGoTestName reports whether name is a test function name for prefix, the way go test decides it:
+// the prefix alone, or followed by something that doesn't start with a lower case letter.
+func isGoTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// goComplexity is the cyclomatic complexity of a function body: one plus every if, for, non-default
+// case, && and ||. Function literals count toward the function they are in.
+func goComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	if body == nil {
+		return complexity // declared without a body, e.g. implemented in assembly
+	}
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if node.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// addGoFile adds a file's Go metrics to the running totals. packages tracks the package
+// directories seen so far, since a package spans several files.
+func (g *GoMetrics) addGoFile(relPath string, file goFileMetrics, packages map[string]struct{}) {
+	if file.parseFail {
+		g.ParseErrors++
+		return
+	}
+
+	key := filepath.ToSlash(filepath.Dir(relPath)) + ":" + file.pkg
+	if _, ok := packages[key]; !ok {
+		packages[key] = struct{}{}
+		g.Packages++
+	}
+	g.Files += file.metrics.Files
+	g.ExportedFuncs += file.metrics.ExportedFuncs
+	g.UnexportedFuncs += file.metrics.UnexportedFuncs
+	g.Methods += file.metrics.Methods
+	g.Types += file.metrics.Types
+	g.Interfaces += file.metrics.Interfaces
+	g.TestFuncs += file.metrics.TestFuncs
+	g.Benchmarks += file.metrics.Benchmarks
+	for _, function := range file.metrics.Functions {
+		function.Path = relPath
+		g.Functions = append(g.Functions, function)
+	}
+}
+
+// sortGoFunctions orders functions most complex first, then longest first, then by path and line.
+func sortGoFunctions(functions []GoFunctionMetrics) {
+	sort.Slice(functions, func(i, j int) bool {
+		a, b := functions[i], functions[j]
+		switch {
+		case a.Complexity != b.Complexity:
+			return a.Complexity > b.Complexity
+		case a.Lines != b.Lines:
+			return a.Lines > b.Lines
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		default:
+			return a.Line < b.Line
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package pathfinder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanGoMetrics(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"shape/shape.go": `package shape
+
+// "func Fake() {}" in a comment isn't a function
+type Shape interface{ Area() float64 }
+
+type Square struct{ side float64 }
+
+func (s *Square) Area() float64 { return s.side * s.side }
+
+func New(side float64) Shape {
+	if side < 0 || side > 100 {
+		return nil
+	}
+	return &Square{side: side}
+}
+
+func clamp(x float64) float64 {
+	switch {
+	case x < 0:
+		return 0
+	default:
+		return x
+	}
+}
+`,
+		"shape/shape_test.go": `package shape
+
+import "testing"
+
+func TestNew(t *testing.T)           {}
+func Testable()                       {}
+func BenchmarkNew(b *testing.B)      {}
+`,
+		"broken/broken.go": "package broken\n\nfunc {\n",
+	})
+
+	report, err := Scan(Config{PathFlag: root, RecursiveFlag: true, MaxDepthFlag: -1, GoMetricsFlag: true})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	got := report.GoMetrics
+	functions := got.Functions
+	got.Functions = nil
+	want := GoMetrics{
+		Packages:        1,
+		Files:           2,
+		ParseErrors:     1,
+		ExportedFuncs:   4,
+		UnexportedFuncs: 1,
+		Methods:         1,
+		Types:           2,
+		Interfaces:      1,
+		TestFuncs:       1,
+		Benchmarks:      1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GoMetrics = %+v, want %+v", got, want)
+	}
+
+	if len(functions) != 6 {
+		t.Fatalf("Functions = %+v, want 6", functions)
+	}
+	wantFirst := GoFunctionMetrics{Path: "shape/shape.go", Name: "New", Line: 10, Lines: 6, Complexity: 3, Exported: true}
+	if functions[0] != wantFirst {
+		t.Fatalf("Functions[0] = %+v, want %+v", functions[0], wantFirst)
+	}
+	if functions[1].Name != "clamp" || functions[1].Complexity != 2 {
+		t.Fatalf("Functions[1] = %+v, want clamp with complexity 2", functions[1])
+	}
+	for _, function := range functions {
+		if function.Name == "(*Square).Area" {
+			return
+		}
+	}
+	t.Fatalf("Functions = %+v, want the (*Square).Area method", functions)
+}
@@ -0,0 +1,221 @@
+package pathfinder
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileNames are read from every directory in the order listed, so .ignore rules take precedence over .gitignore.
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// ignoreRule is a single parsed line of a .gitignore-style file.
+type ignoreRule struct {
+	segments []string // pattern split on "/", with "**" kept as its own segment
+	negate   bool     // pattern started with "!" and re-includes matching paths
+	dirOnly  bool     // pattern ended with "/" and only matches directories
+}
+
+// ignoreFile holds the rules of one ignore file, matched relative to the directory it was found in.
+type ignoreFile struct {
+	base  string
+	rules []ignoreRule
+}
+
+// ignoreMatcher implements gitignore semantics for the directory walk.
+// Rules are evaluated from the outermost directory down to the parent of a path and the last matching rule wins.
+type ignoreMatcher struct {
+	root  string                  // absolute scan root
+	outer []ignoreFile            // .git/info/exclude and ignore files above the scan root, outermost first
+	dirs  map[string][]ignoreFile // ignore files found during the walk, keyed by the directory containing them
+}
+
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	m := &ignoreMatcher{
+		root: root,
+		dirs: map[string][]ignoreFile{},
+	}
+
+	repoRoot, ok := findRepoRoot(root)
+	if !ok {
+		return m
+	}
+
+	// info/exclude has the lowest precedence, followed by ignore files between the repo root and the scan root
+	if rules := parseIgnoreFile(filepath.Join(repoRoot, ".git", "info", "exclude")); len(rules) > 0 {
+		m.outer = append(m.outer, ignoreFile{base: repoRoot, rules: rules})
+	}
+
+	var parents []string
+	for dir := root; dir != repoRoot; {
+		dir = filepath.Dir(dir)
+		parents = append([]string{dir}, parents...)
+	}
+	for _, dir := range parents {
+		m.outer = append(m.outer, loadIgnoreFiles(dir)...)
+	}
+
+	return m
+}
+
+// findRepoRoot walks up from dir looking for a .git directory or file (worktrees and submodules use a file).
+func findRepoRoot(dir string) (string, bool) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// enterDir loads the ignore files of a directory the walker is about to descend into.
+func (m *ignoreMatcher) enterDir(dir string) {
+	if files := loadIgnoreFiles(dir); len(files) > 0 {
+		m.dirs[dir] = files
+	}
+}
+
+// ignored reports whether path (absolute, below the scan root) is excluded by any applicable ignore file.
+func (m *ignoreMatcher) ignored(p string, isDir bool) bool {
+	var chain []string
+	for dir := filepath.Dir(p); ; dir = filepath.Dir(dir) {
+		chain = append(chain, dir)
+		if dir == m.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	ignored := false
+	check := func(files []ignoreFile) {
+		for _, file := range files {
+			rel, err := filepath.Rel(file.base, p)
+			if err != nil {
+				continue
+			}
+			parts := strings.Split(filepath.ToSlash(rel), "/")
+
+			for _, rule := range file.rules {
+				if rule.dirOnly && !isDir {
+					continue
+				}
+				if matchIgnoreSegments(rule.segments, parts) {
+					ignored = !rule.negate
+				}
+			}
+		}
+	}
+
+	check(m.outer)
+	for i := len(chain) - 1; i >= 0; i-- {
+		check(m.dirs[chain[i]])
+	}
+	return ignored
+}
+
+func loadIgnoreFiles(dir string) []ignoreFile {
+	var files []ignoreFile
+	for _, name := range ignoreFileNames {
+		if rules := parseIgnoreFile(filepath.Join(dir, name)); len(rules) > 0 {
+			files = append(files, ignoreFile{base: dir, rules: rules})
+		}
+	}
+	return files
+}
+
+func parseIgnoreFile(path string) []ignoreRule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil // missing or unreadable ignore files are treated as empty, like git does
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	// trailing spaces are ignored unless escaped with a backslash
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	var rule ignoreRule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// a pattern without a slash (other than a trailing one) matches at any depth below the ignore file
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if !anchored {
+		line = "**/" + line
+	}
+
+	for _, segment := range strings.Split(line, "/") {
+		if segment == "" {
+			continue
+		}
+		// gitignore uses [!...] for negated classes where path.Match expects [^...]
+		segment = strings.ReplaceAll(segment, "[!", "[^")
+		rule.segments = append(rule.segments, segment)
+	}
+
+	return rule, true
+}
+
+func matchIgnoreSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				// a trailing "**" matches everything inside, but not the directory itself
+				return len(parts) > 0
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchIgnoreSegments(pattern, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
@@ -0,0 +1,114 @@
+package pathfinder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseIgnoreLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{line: "*.log", path: "a/b/debug.log", want: true},
+		{line: "/*.log", path: "a/debug.log", want: false},
+		{line: "/*.log", path: "debug.log", want: true},
+		{line: "build/", path: "src/build", isDir: true, want: true},
+		{line: "build/", path: "src/build", isDir: false, want: false},
+		{line: "docs/**/*.gen.go", path: "docs/a/b/x.gen.go", want: true},
+		{line: "docs/**/*.gen.go", path: "docs/x.gen.go", want: true},
+		{line: "docs/**", path: "docs", isDir: true, want: false},
+		{line: "a/**/b", path: "a/x/y/b", want: true},
+		{line: "file[!0-9].txt", path: "fileA.txt", want: true},
+		{line: "file[!0-9].txt", path: "file1.txt", want: false},
+		{line: `\#notes`, path: "#notes", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line+" "+tt.path, func(t *testing.T) {
+			rule, ok := parseIgnoreLine(tt.line)
+			if !ok {
+				t.Fatalf("parseIgnoreLine(%q) returned no rule", tt.line)
+			}
+			got := (!rule.dirOnly || tt.isDir) && matchIgnoreSegments(rule.segments, strings.Split(tt.path, "/"))
+			if got != tt.want {
+				t.Fatalf("match(%q, %q) = %v, want %v", tt.line, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanHonorsIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".git/info/exclude":  "local.go\n",
+		".gitignore":         "*.gen.go\ngenerated/\n!keep.gen.go\n",
+		"main.go":            "package main\n",
+		"local.go":           "package main\n",
+		"api.gen.go":         "package main\n",
+		"keep.gen.go":        "package main\n",
+		"generated/types.go": "package generated\n",
+		"sub/.gitignore":     "*.py\n",
+		"sub/script.py":      "print(1)\n",
+		"sub/lib.go":         "package sub\n",
+		"other/script.py":    "print(1)\n",
+		"other/.ignore":      "!script.py\nscratch.go\n",
+		"other/scratch.go":   "package other\n",
+	})
+
+	tests := []struct {
+		name     string
+		noIgnore bool
+		want     []string
+	}{
+		{
+			name: "ignore files honored",
+			want: []string{"keep.gen.go", "main.go", "other/script.py", "sub/lib.go"},
+		},
+		{
+			name:     "ignore files disabled",
+			noIgnore: true,
+			want: []string{
+				"api.gen.go", "generated/types.go", "keep.gen.go", "local.go", "main.go",
+				"other/scratch.go", "other/script.py", "sub/lib.go", "sub/script.py",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Scan(Config{PathFlag: root, RecursiveFlag: true, NoIgnoreFlag: tt.noIgnore})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			got := make([]string, 0, len(report.FileMetrics))
+			for _, file := range report.ScannedFiles() {
+				got = append(got, filepath.ToSlash(file))
+			}
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("scanned files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,139 +1,264 @@
 package pathfinder
 
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// languages.json holds the built-in language catalog, one definition per line sorted by name.
+// Adding a language only needs a new line there, in the same shape as LanguageDefinition.
+//
+//go:embed languages.json
+var languagesJSON []byte
+
 var (
-	languageDefinitions = []LanguageDefinition{
-		{
-			Name: "Go",
-			Type: slashType(),
-			Ext:  []string{".go"},
-		},
-		{
-			Name: "JavaScript",
-			Type: slashType(),
-			Ext:  []string{".js", ".jsx", ".mjs"},
-		},
-		{
-			Name: "TypeScript",
-			Type: slashType(),
-			Ext:  []string{".ts", ".tsx", ".mts"},
-		},
-		{
-			Name: "PHP",
-			Type: slashType(),
-			Ext:  []string{".php"},
-		},
-		{
-			Name: "HTML",
-			Type: htmlType(),
-			Ext:  []string{".html", ".htm"},
-		},
-		{
-			Name: "CSS",
-			Type: slashType(),
-			Ext:  []string{".css", ".scss", ".sass"},
-		},
-		{
-			Name: "XML",
-			Type: htmlType(),
-			Ext:  []string{".xml"},
-		},
-		{
-			Name: "Python",
-			Type: hashType(),
-			Ext:  []string{".py", ".pyc"},
-		},
-		{
-			Name: "Java",
-			Type: slashType(),
-			Ext:  []string{".java"},
-		},
-		{
-			Name: "Kotlin",
-			Type: slashType(),
-			Ext:  []string{".kt", ".kts"},
-		},
-		{
-			Name: "C",
-			Type: slashType(),
-			Ext:  []string{".c", ".h"},
-		},
-		{
-			Name: "C++",
-			Type: slashType(),
-			Ext:  []string{".cpp", ".hpp"},
-		},
-		{
-			Name: "C#",
-			Type: slashType(),
-			Ext:  []string{".cs"},
-		},
-		{
-			Name: "Swift",
-			Type: slashType(),
-			Ext:  []string{".swift"},
-		},
-		{
-			Name: "JSON",
-			Type: noneType(),
-			Ext:  []string{".json"},
-		},
-		{
-			Name: "YAML",
-			Type: hashType(),
-			Ext:  []string{".yaml", ".yml"},
-		},
-		{
-			Name: "Markdown",
-			Type: hashType(),
-			Ext:  []string{".md"},
-		},
-	}
-
-	languageByExt = make(map[string]*LanguageDefinition)
+	builtinLanguages = mustLoadLanguageDefinitions(languagesJSON)
+
+	// languages added with RegisterLanguage, on top of the built-in catalog for every scan
+	registeredMu        sync.RWMutex
+	registeredLanguages []LanguageDefinition
 )
 
-func init() { // this function initializes the languageByExt map by iterating over the languageDefinitions slice
-	for i := range languageDefinitions {
-		for _, ext := range languageDefinitions[i].Ext {
-			languageByExt[ext] = &languageDefinitions[i]
-		}
+// languageRegistry indexes the languages a scan knows about by extension, filename and interpreter.
+type languageRegistry struct {
+	definitions []*LanguageDefinition
+
+	// extensions can be shared by several languages (e.g. ".h" or ".m"), which are told apart by content heuristics
+	byExt         map[string][]*LanguageDefinition
+	byFilename    map[string]*LanguageDefinition
+	byInterpreter map[string]*LanguageDefinition
+}
+
+// mustLoadLanguageDefinitions panics on a malformed catalog, since it is embedded at build time.
+func mustLoadLanguageDefinitions(data []byte) []LanguageDefinition {
+	var definitions []LanguageDefinition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		panic("pathfinder: invalid embedded languages.json: " + err.Error())
 	}
+	return definitions
 }
 
-func determineLangByExt(ext string) *LanguageDefinition {
-	if lang, ok := languageByExt[ext]; ok {
-		return lang
+// RegisterLanguage adds a custom language to every following scan. A custom language with the same name
+// as a built-in one replaces it, and its extensions, filenames and interpreters take precedence over the
+// built-in ones. It returns an error if the definition is invalid or conflicts with another registered language.
+func RegisterLanguage(langDef LanguageDefinition) error {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	languages := append(append([]LanguageDefinition{}, registeredLanguages...), langDef)
+	if err := validateLanguages(languages); err != nil {
+		return err
 	}
+	registeredLanguages = languages
 	return nil
 }
 
-func slashType() CommentType {
-	return CommentType{
-		SingleLine: "//",
-		BlockStart: "/*",
-		BlockEnd:   "*/",
+// LoadLanguages reads custom language definitions from a JSON or YAML file (picked by its extension),
+// in the same shape as the built-in languages.json catalog:
+//
+//	[{"name": "Pipeline", "comments": {"line": ["#"]}, "extensions": [".pipeline"]}]
+func LoadLanguages(path string) ([]LanguageDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read languages file %s: %w", path, err)
+	}
+
+	var definitions []LanguageDefinition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &definitions)
+	default:
+		err = json.Unmarshal(data, &definitions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse languages file %s: %w", path, err)
+	}
+
+	if err := validateLanguages(definitions); err != nil {
+		return nil, fmt.Errorf("invalid languages file %s: %w", path, err)
+	}
+	return definitions, nil
+}
+
+// validateLanguages checks each custom definition and that no two of them claim the same extension,
+// filename or interpreter, since it would be ambiguous which one wins.
+func validateLanguages(languages []LanguageDefinition) error {
+	names := make(map[string]string)
+	claims := make(map[string]string)
+	claim := func(kind, key, name string) error {
+		if other, ok := claims[kind+"\x00"+key]; ok {
+			return fmt.Errorf("%s %q is claimed by both %q and %q", kind, key, other, name)
+		}
+		claims[kind+"\x00"+key] = name
+		return nil
+	}
+
+	for _, langDef := range languages {
+		if strings.TrimSpace(langDef.Name) == "" {
+			return errors.New("language definition is missing a name")
+		}
+		if other, ok := names[strings.ToLower(langDef.Name)]; ok {
+			return fmt.Errorf("language %q is defined more than once (as %q)", langDef.Name, other)
+		}
+		names[strings.ToLower(langDef.Name)] = langDef.Name
+
+		if len(langDef.Ext) == 0 && len(langDef.Filenames) == 0 && len(langDef.Interpreters) == 0 {
+			return fmt.Errorf("language %q needs at least one extension, filename or interpreter", langDef.Name)
+		}
+		for _, marker := range langDef.Type.SingleLine {
+			if strings.TrimSpace(marker) == "" {
+				return fmt.Errorf("language %q has an empty line comment marker", langDef.Name)
+			}
+		}
+		for _, marker := range langDef.Type.FirstColumn {
+			if strings.TrimSpace(marker) == "" || marker != strings.TrimLeft(marker, " \t") {
+				return fmt.Errorf("language %q has an empty or indented first column comment marker", langDef.Name)
+			}
+		}
+		for _, block := range langDef.Type.Blocks {
+			if block.Start == "" || block.End == "" {
+				return fmt.Errorf("language %q needs both start and end for block comments", langDef.Name)
+			}
+		}
+
+		if langDef.DocBefore != "" {
+			if _, err := regexp.Compile(langDef.DocBefore); err != nil {
+				return fmt.Errorf("language %q has an invalid doc_before pattern: %w", langDef.Name, err)
+			}
+		}
+		for _, token := range langDef.Complexity {
+			if token == "" || strings.ContainsAny(token, " \t") {
+				return fmt.Errorf("language %q has invalid complexity token %q, tokens can't be empty or contain spaces", langDef.Name, token)
+			}
+		}
+		if langDef.Functions != nil {
+			for _, pattern := range langDef.Functions.Patterns {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("language %q has an invalid function pattern: %w", langDef.Name, err)
+				}
+				if re.SubexpIndex("name") == -1 {
+					return fmt.Errorf("language %q has a function pattern without a (?P<name>...) group: %q", langDef.Name, pattern)
+				}
+			}
+		}
+		switch langDef.Embedded {
+		case "", EmbedHTML, EmbedMarkdown, EmbedNotebook:
+		default:
+			return fmt.Errorf("language %q has invalid embedded mode %q. Allowed values are html, markdown, notebook", langDef.Name, langDef.Embedded)
+		}
+		for _, str := range langDef.Strings {
+			if str.Start == "" || str.End == "" {
+				return fmt.Errorf("language %q needs both start and end for string literals", langDef.Name)
+			}
+		}
+
+		for _, ext := range langDef.Ext {
+			if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+				return fmt.Errorf("language %q has invalid extension %q, extensions must start with a dot", langDef.Name, ext)
+			}
+			if err := claim("extension", ext, langDef.Name); err != nil {
+				return err
+			}
+		}
+		for _, filename := range langDef.Filenames {
+			if err := claim("filename", filename, langDef.Name); err != nil {
+				return err
+			}
+		}
+		for _, interpreter := range langDef.Interpreters {
+			if err := claim("interpreter", interpreter, langDef.Name); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
 
-func htmlType() CommentType {
-	return CommentType{
-		BlockStart: "<!--",
-		BlockEnd:   "-->",
+// scanLanguages builds the registry for a scan: the built-in catalog, then registered languages,
+// then the ones passed in Config.Languages. Custom languages must not conflict with each other.
+func scanLanguages(custom []LanguageDefinition) (*languageRegistry, error) {
+	registeredMu.RLock()
+	languages := append(append([]LanguageDefinition{}, registeredLanguages...), custom...)
+	registeredMu.RUnlock()
+
+	if err := validateLanguages(languages); err != nil {
+		return nil, err
 	}
+	return newLanguageRegistry(languages), nil
 }
 
-func hashType() CommentType {
-	return CommentType{
-		SingleLine: "#",
+// newLanguageRegistry indexes the built-in catalog with custom languages layered on top.
+// custom is assumed to be validated and is owned by the registry afterwards.
+func newLanguageRegistry(custom []LanguageDefinition) *languageRegistry {
+	registry := &languageRegistry{
+		byExt:         make(map[string][]*LanguageDefinition),
+		byFilename:    make(map[string]*LanguageDefinition),
+		byInterpreter: make(map[string]*LanguageDefinition),
 	}
+
+	replaced := make(map[string]bool, len(custom))
+	for _, langDef := range custom {
+		replaced[strings.ToLower(langDef.Name)] = true
+	}
+
+	for i := range builtinLanguages {
+		if !replaced[strings.ToLower(builtinLanguages[i].Name)] {
+			registry.add(&builtinLanguages[i], false)
+		}
+	}
+	for i := range custom {
+		registry.add(&custom[i], true)
+	}
+	return registry
 }
 
-func dashType() CommentType {
-	return CommentType{
-		SingleLine: "--",
+// add indexes langDef. Custom languages take an extension over completely rather than becoming
+// one more candidate, so a custom ".tmpl" never gets second-guessed by content heuristics.
+func (r *languageRegistry) add(langDef *LanguageDefinition, custom bool) {
+	r.definitions = append(r.definitions, langDef)
+	for _, ext := range langDef.Ext {
+		if custom {
+			r.byExt[ext] = []*LanguageDefinition{langDef}
+		} else {
+			r.byExt[ext] = append(r.byExt[ext], langDef)
+		}
+	}
+	for _, name := range langDef.Filenames {
+		r.byFilename[name] = langDef
 	}
+	for _, interpreter := range langDef.Interpreters {
+		r.byInterpreter[interpreter] = langDef
+	}
+}
+
+// determineLangByExt returns every language using ext, in definition order.
+func (r *languageRegistry) determineLangByExt(ext string) []*LanguageDefinition {
+	return r.byExt[ext]
 }
 
-func noneType() CommentType {
-	return CommentType{}
+func (r *languageRegistry) determineLangByFilename(name string) *LanguageDefinition {
+	return r.byFilename[name]
+}
+
+func (r *languageRegistry) determineLangByInterpreter(interpreter string) *LanguageDefinition {
+	return r.byInterpreter[interpreter]
+}
+
+func (r *languageRegistry) determineLangByName(name string) *LanguageDefinition {
+	for _, langDef := range r.definitions {
+		if strings.EqualFold(langDef.Name, name) {
+			return langDef
+		}
+	}
+	return nil
 }
@@ -0,0 +1,109 @@
+package pathfinder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLanguageCatalog(t *testing.T) {
+	seen := map[string]bool{}
+	for _, langDef := range builtinLanguages {
+		if langDef.Name == "" {
+			t.Fatalf("language without a name: %+v", langDef)
+		}
+		if seen[langDef.Name] {
+			t.Fatalf("duplicate language %q", langDef.Name)
+		}
+		seen[langDef.Name] = true
+
+		if len(langDef.Ext) == 0 && len(langDef.Filenames) == 0 && len(langDef.Interpreters) == 0 {
+			t.Fatalf("language %q can never be detected", langDef.Name)
+		}
+		for _, block := range langDef.Type.Blocks {
+			if block.Start == "" || block.End == "" {
+				t.Fatalf("language %q has an unbalanced block comment: %+v", langDef.Name, block)
+			}
+		}
+	}
+}
+
+func TestValidateLanguages(t *testing.T) {
+	tmpl := LanguageDefinition{Name: "Template", Type: CommentType{Blocks: []BlockComment{{Start: "{{/*", End: "*/}}"}}}, Ext: []string{".tmpl"}}
+
+	tests := []struct {
+		name      string
+		languages []LanguageDefinition
+		wantErr   string
+	}{
+		{name: "valid", languages: []LanguageDefinition{tmpl}},
+		{name: "override built-in", languages: []LanguageDefinition{{Name: "go", Type: CommentType{SingleLine: []string{"//"}}, Ext: []string{".go"}}}},
+		{name: "missing name", languages: []LanguageDefinition{{Ext: []string{".x"}}}, wantErr: "missing a name"},
+		{name: "undetectable", languages: []LanguageDefinition{{Name: "Nothing"}}, wantErr: "at least one extension"},
+		{name: "bad extension", languages: []LanguageDefinition{{Name: "Pipeline", Ext: []string{"pipeline"}}}, wantErr: "must start with a dot"},
+		{name: "unbalanced block", languages: []LanguageDefinition{{Name: "Half", Type: CommentType{Blocks: []BlockComment{{Start: "/*"}}}, Ext: []string{".half"}}}, wantErr: "start and end"},
+		{name: "indented first column marker", languages: []LanguageDefinition{{Name: "Fixed", Type: CommentType{FirstColumn: []string{" *"}}, Ext: []string{".fixed"}}}, wantErr: "first column"},
+		{name: "duplicate name", languages: []LanguageDefinition{tmpl, {Name: "template", Ext: []string{".tpl"}}}, wantErr: "more than once"},
+		{
+			name:      "conflicting extension",
+			languages: []LanguageDefinition{tmpl, {Name: "Jinja", Ext: []string{".j2", ".tmpl"}}},
+			wantErr:   `extension ".tmpl" is claimed by both "Template" and "Jinja"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLanguages(tt.languages)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateLanguages() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateLanguages() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScanCustomLanguages(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"deploy.pipeline": "# build stage\nstage build\n\n# test stage\nstage test\n",
+		"main.go":         "package main\n",
+	})
+
+	languagesFile := filepath.Join(t.TempDir(), "languages.yaml")
+	yamlDefs := "- name: Pipeline\n  comments:\n    line: [\"#\"]\n  extensions: [.pipeline]\n"
+	if err := os.WriteFile(languagesFile, []byte(yamlDefs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	languages, err := LoadLanguages(languagesFile)
+	if err != nil {
+		t.Fatalf("LoadLanguages() error = %v", err)
+	}
+
+	report, err := Scan(Config{PathFlag: root, Languages: languages})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	got := map[string]LanguageMetrics{}
+	for _, lang := range report.LanguageMetrics {
+		got[lang.Metrics.Language] = lang.Metrics
+	}
+	pipeline, ok := got["Pipeline"]
+	if !ok || got["Go"].Files != 1 {
+		t.Fatalf("scanned languages = %v, want Pipeline and Go", report.ScannedLanguages())
+	}
+	if pipeline.Code != 2 || pipeline.Comments != 2 || pipeline.Blanks != 1 {
+		t.Fatalf("Pipeline metrics = %+v, want 2 code, 2 comments and 1 blank", pipeline)
+	}
+
+	conflicting := append(languages, LanguageDefinition{Name: "Other", Ext: []string{".pipeline"}})
+	if _, err := Scan(Config{PathFlag: root, Languages: conflicting}); err == nil {
+		t.Fatal("Scan() with conflicting languages error = nil, want an error")
+	}
+}
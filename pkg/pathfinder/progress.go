@@ -0,0 +1,80 @@
+package pathfinder
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// progressReporter serializes progress events from the walker and the result consumers
+// and keeps the running totals attached to every event.
+type progressReporter struct {
+	mu       sync.Mutex
+	rootPath string
+	fn       func(ProgressEvent)
+	totals   ProgressEvent
+}
+
+// newProgressReporter returns nil when no callback is configured, which makes every emit a no-op.
+func newProgressReporter(flags Config) *progressReporter {
+	if flags.OnProgress == nil {
+		return nil
+	}
+	return &progressReporter{rootPath: flags.PathFlag, fn: flags.OnProgress}
+}
+
+func (p *progressReporter) dirEntered(path string) {
+	if p == nil {
+		return
+	}
+	p.emit(ProgressEvent{Kind: ProgressDirEntered, Path: p.relPath(path)})
+}
+
+func (p *progressReporter) fileCounted(path string, metrics LanguageMetrics) {
+	if p == nil {
+		return
+	}
+	p.emit(ProgressEvent{Kind: ProgressFileCounted, Path: p.relPath(path), Metrics: metrics})
+}
+
+func (p *progressReporter) dependencyParsed(file DependencyFile) {
+	if p == nil {
+		return
+	}
+	p.emit(ProgressEvent{Kind: ProgressDependencyParsed, Path: p.relPath(file.Path), Dependency: file})
+}
+
+func (p *progressReporter) scanError(scanErr ScanError) {
+	if p == nil {
+		return
+	}
+	p.emit(ProgressEvent{Kind: ProgressError, Path: scanErr.Path, Error: &scanErr})
+}
+
+func (p *progressReporter) emit(event ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event.Kind {
+	case ProgressDirEntered:
+		p.totals.DirsEntered++
+	case ProgressFileCounted:
+		p.totals.FilesCounted++
+		p.totals.LinesCounted += event.Metrics.Total().Lines
+	case ProgressError:
+		p.totals.Errors++
+	}
+
+	event.DirsEntered = p.totals.DirsEntered
+	event.FilesCounted = p.totals.FilesCounted
+	event.LinesCounted = p.totals.LinesCounted
+	event.Errors = p.totals.Errors
+	p.fn(event)
+}
+
+func (p *progressReporter) relPath(path string) string {
+	relPath, err := filepath.Rel(p.rootPath, path)
+	if err != nil {
+		return path
+	}
+	return relPath
+}
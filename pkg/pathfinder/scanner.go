@@ -1,9 +1,9 @@
 package pathfinder
 
 import (
+	"context"
 	"errors"
 	"io/fs"
-	"log"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -13,45 +13,70 @@ import (
 )
 
 type scanJob struct {
-	path    string
-	langDef *LanguageDefinition
+	path       string
+	langDef    *LanguageDefinition
+	candidates []*LanguageDefinition // set instead of langDef when the extension is shared by several languages
+	sniff      bool                  // set for extensionless files, whose language comes from a shebang or modeline
 }
 
 // pass data from workers back to main goroutine
 type scanResult struct {
 	fileMetrics LanguageMetrics
 	annMetrics  AnnotationMetrics
+	encoding    Encoding
+	goFile      *goFileMetrics         // only set for Go files if GoMetricsFlag is true
+	functions   []FunctionMetrics      // only set if FunctionMetricsFlag is true
+	duplicates  *duplicateFingerprints // only set if DuplicatesFlag is true
+	generated   string                 // why the file looks generated, see FileMetricsReport.Generated
 	path        string
+	cellLines   bool // annotation lines are within notebook cells rather than the file, so they can't be blamed
 	err         error
 }
 
 type scanAggregation struct {
 	langStatsMap    map[string]*LanguageMetrics
-	dirStatsMap     map[string]int
+	dirStatsMap     map[string]*LanguageMetrics // keyed by top level directory, Language is unused
+	classStatsMap   map[FileClass]*LanguageMetrics
+	testStats       *testSplit
+	duplicates      *duplicateIndex
 	codebaseStats   CodebaseMetrics
 	annotationStats AnnotationMetrics
+	generatedStats  GeneratedMetrics
+	goStats         GoMetrics
+	goPackages      map[string]struct{} // packages counted in goStats, see GoMetrics.addGoFile
 	dependencyStats DependencyMetrics
+	gitHistory      gitHistory
 	topFilesList    []FileMetricsReport
+	errors          []ScanError
+	cellLinePaths   map[string]bool // files whose annotation lines can't be blamed, see scanResult.cellLines
+	firstErr        error           // first file error, only set when FailFastFlag is true
+	progress        *progressReporter
 }
 
-func scanCodebase(flags Config) (CodebaseReport, error) {
+func scanCodebase(ctx context.Context, flags Config, languages *languageRegistry) (CodebaseReport, error) {
 	if flags.PathFlag == "" { // won't ever happen since default is "." set by cobra
 		return CodebaseReport{}, errors.New("path is required")
 	}
 
+	// the pipeline gets its own cancel so fail-fast mode can stop it without touching the caller's context
+	pipelineCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	startTime := time.Now()
 	locJobs := make(chan scanJob, 100)
 	locResults := make(chan scanResult, 100)
 	depJobs := make(chan DependencyFile, 100)
 	depResults := make(chan DependencyFile, 100)
 
-	workers, waitForLocWorkers := startScanWorkers(flags, locJobs, locResults)
-	waitForDepWorkers := startDependencyWorkers(flags, depJobs, depResults)
+	workers, waitForLocWorkers := startScanWorkers(pipelineCtx, flags, languages, locJobs, locResults)
+	waitForDepWorkers := startDependencyWorkers(pipelineCtx, flags, depJobs, depResults)
 
 	aggregation := newScanAggregation()
-	waitForResults := startResultConsumers(flags, locResults, depResults, aggregation)
+	aggregation.progress = newProgressReporter(flags)
+	waitForResults := startResultConsumers(flags, locResults, depResults, aggregation, cancel)
+	waitForGit := startGitAnalysis(pipelineCtx, flags, aggregation)
 
-	totalDirs, walkErr := walkCodebase(flags, locJobs, depJobs)
+	totalDirs, walkErrors, walkErr := walkCodebase(pipelineCtx, flags, languages, locJobs, depJobs, aggregation.progress)
 	close(locJobs)
 	if flags.DependencyFlag {
 		close(depJobs)
@@ -64,16 +89,39 @@ func scanCodebase(flags Config) (CodebaseReport, error) {
 		close(depResults)
 	}
 	waitForResults()
+	gitErr := waitForGit()
+
+	var blameErr error
+	if flags.BlameAnnotationsFlag && pipelineCtx.Err() == nil {
+		blameErr = blameAnnotations(pipelineCtx, flags, aggregation.annotationStats.Annotations, aggregation.cellLinePaths, time.Now())
+	}
+
+	aggregation.codebaseStats.TotalDirs = totalDirs
+	aggregation.errors = append(aggregation.errors, walkErrors...)
 
+	// the caller gave up, so hand back whatever was aggregated before the pipeline drained
+	if err := ctx.Err(); err != nil {
+		report := buildCodebaseReport(flags, startTime, workers, aggregation)
+		report.Incomplete = true
+		return report, err
+	}
+	if aggregation.firstErr != nil {
+		return CodebaseReport{}, aggregation.firstErr
+	}
 	if walkErr != nil {
 		return CodebaseReport{}, walkErr
 	}
+	if gitErr != nil {
+		return CodebaseReport{}, gitErr
+	}
+	if blameErr != nil {
+		return CodebaseReport{}, blameErr
+	}
 
-	aggregation.codebaseStats.TotalDirs = totalDirs
 	return buildCodebaseReport(flags, startTime, workers, aggregation), nil
 }
 
-func startScanWorkers(flags Config, jobs <-chan scanJob, results chan<- scanResult) ([]*WorkerStats, func()) {
+func startScanWorkers(ctx context.Context, flags Config, languages *languageRegistry, jobs <-chan scanJob, results chan<- scanResult) ([]*WorkerStats, func()) {
 	var wg sync.WaitGroup
 	workers := make([]*WorkerStats, flags.WorkerFlag)
 
@@ -85,12 +133,46 @@ func startScanWorkers(flags Config, jobs <-chan scanJob, results chan<- scanResu
 			defer wg.Done()
 
 			for job := range jobs {
-				fileMetrics, annotationMetrics, err := fileCounter(job.path, flags.BufferSizeFlag, job.langDef)
+				if ctx.Err() != nil {
+					continue // drain queued jobs without reading the files
+				}
+
+				langDef := job.langDef
+				if langDef == nil {
+					var err error
+					langDef, err = languages.detectLanguage(job.path, job.candidates)
+					if err != nil && job.sniff {
+						continue // unreadable extensionless files may not be code at all, so they aren't errors
+					}
+					if err != nil {
+						results <- scanResult{path: job.path, err: err}
+						continue
+					}
+					if langDef == nil {
+						continue
+					}
+				}
+
+				count, err := fileCounter(job.path, flags, languages, langDef)
+				if errors.Is(err, errBinaryContent) {
+					continue
+				}
+				var goFile *goFileMetrics
+				if err == nil && flags.GoMetricsFlag && langDef.Name == "Go" {
+					analyzed := analyzeGoFile(job.path)
+					goFile = &analyzed
+				}
 				ws.Processed++
 				results <- scanResult{
-					fileMetrics: fileMetrics,
-					annMetrics:  annotationMetrics,
+					goFile:      goFile,
+					functions:   count.functions,
+					duplicates:  count.duplicates,
+					fileMetrics: count.metrics,
+					annMetrics:  count.annotations,
+					encoding:    count.encoding,
+					generated:   count.generated,
 					path:        job.path,
+					cellLines:   langDef.Embedded == EmbedNotebook,
 					err:         err,
 				}
 			}
@@ -104,7 +186,7 @@ func startScanWorkers(flags Config, jobs <-chan scanJob, results chan<- scanResu
 	return workers, wg.Wait
 }
 
-func startDependencyWorkers(flags Config, jobs <-chan DependencyFile, results chan<- DependencyFile) func() {
+func startDependencyWorkers(ctx context.Context, flags Config, jobs <-chan DependencyFile, results chan<- DependencyFile) func() {
 	var wg sync.WaitGroup
 	if !flags.DependencyFlag {
 		return wg.Wait
@@ -115,6 +197,10 @@ func startDependencyWorkers(flags Config, jobs <-chan DependencyFile, results ch
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
 				dependencies, err := scanDependencyFile(job)
 				if err == nil && len(dependencies) > 0 {
 					job.Dependencies = dependencies
@@ -144,21 +230,46 @@ func scanDependencyFile(file DependencyFile) ([]string, error) {
 	}
 }
 
+// startGitAnalysis reads the git history alongside the file pipeline, since it only depends on the root path.
+func startGitAnalysis(ctx context.Context, flags Config, aggregation *scanAggregation) func() error {
+	if !flags.GitFlag {
+		return func() error { return nil }
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		history, err := scanGitHistory(ctx, flags.PathFlag)
+		aggregation.gitHistory = history
+		done <- err
+	}()
+
+	return func() error { return <-done }
+}
+
 func newScanAggregation() *scanAggregation {
 	return &scanAggregation{
-		langStatsMap: map[string]*LanguageMetrics{},
-		dirStatsMap:  map[string]int{},
-		topFilesList: make([]FileMetricsReport, 0),
+		langStatsMap:  map[string]*LanguageMetrics{},
+		dirStatsMap:   map[string]*LanguageMetrics{},
+		classStatsMap: map[FileClass]*LanguageMetrics{},
+		testStats:     newTestSplit(),
+		duplicates:    newDuplicateIndex(),
+		goPackages:    map[string]struct{}{},
+		cellLinePaths: map[string]bool{},
+		topFilesList:  make([]FileMetricsReport, 0),
+		errors:        make([]ScanError, 0),
 	}
 }
 
-func startResultConsumers(flags Config, locResults <-chan scanResult, depResults <-chan DependencyFile, aggregation *scanAggregation) func() {
+func startResultConsumers(flags Config, locResults <-chan scanResult, depResults <-chan DependencyFile, aggregation *scanAggregation, cancel context.CancelFunc) func() {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for result := range locResults {
-			aggregateScanResult(flags.PathFlag, result, aggregation)
+			aggregateScanResult(flags, result, aggregation)
+			if aggregation.firstErr != nil {
+				cancel() // fail-fast: stop the walker and let the workers drain
+			}
 		}
 	}()
 
@@ -168,6 +279,7 @@ func startResultConsumers(flags Config, locResults <-chan scanResult, depResults
 			defer wg.Done()
 			for result := range depResults {
 				aggregation.dependencyStats.DependencyFiles = append(aggregation.dependencyStats.DependencyFiles, result)
+				aggregation.progress.dependencyParsed(result)
 				aggregation.dependencyStats.TotalDependencies += len(result.Dependencies)
 			}
 		}()
@@ -176,45 +288,119 @@ func startResultConsumers(flags Config, locResults <-chan scanResult, depResults
 	return wg.Wait
 }
 
-func aggregateScanResult(rootPath string, result scanResult, aggregation *scanAggregation) {
+func aggregateScanResult(flags Config, result scanResult, aggregation *scanAggregation) {
 	if result.err != nil {
-		log.Fatal("Error processing file", result.path, ":", result.err)
+		scanErr := newScanError(flags.PathFlag, result.path, result.err)
+		if flags.FailFastFlag && aggregation.firstErr == nil {
+			aggregation.firstErr = scanErr
+		}
+		aggregation.errors = append(aggregation.errors, scanErr)
+		aggregation.progress.scanError(scanErr)
+		return
 	}
 
-	aggregation.codebaseStats.TotalFiles += result.fileMetrics.Files
-	aggregation.codebaseStats.TotalCode += result.fileMetrics.Code
-	aggregation.codebaseStats.TotalComments += result.fileMetrics.Comments
-	aggregation.codebaseStats.TotalBlanks += result.fileMetrics.Blanks
+	relPath, _ := filepath.Rel(flags.PathFlag, result.path)
+	fileReport := FileMetricsReport{
+		Metrics:   result.fileMetrics,
+		Path:      relPath,
+		Encoding:  result.encoding,
+		Generated: result.generated,
+		Class:     classifyFile(filepath.ToSlash(relPath), result.fileMetrics.Language, result.generated, flags.TestPatternsFlag),
+		Functions: result.functions,
+	}
+	for i := range fileReport.Functions {
+		fileReport.Functions[i].Path = relPath
+	}
 
-	aggregation.annotationStats.TotalTODO += result.annMetrics.TotalTODO
-	aggregation.annotationStats.TotalFIXME += result.annMetrics.TotalFIXME
-	aggregation.annotationStats.TotalHACK += result.annMetrics.TotalHACK
-	aggregation.annotationStats.TotalAnnotations += result.annMetrics.TotalAnnotations
+	// classes cover every counted file, including the generated ones left out of the totals below
+	total := result.fileMetrics.Total()
+	classStats := aggregation.classStatsMap[fileReport.Class]
+	if classStats == nil {
+		classStats = &LanguageMetrics{}
+		aggregation.classStatsMap[fileReport.Class] = classStats
+	}
+	classStats.add(total)
+
+	if result.generated != "" && !flags.IncludeGeneratedFlag {
+		aggregation.generatedStats.TotalFiles++
+		aggregation.generatedStats.TotalCode += total.Code
+		aggregation.generatedStats.TotalLines += total.Lines
+		aggregation.generatedStats.Files = append(aggregation.generatedStats.Files, fileReport)
+		aggregation.progress.fileCounted(result.path, result.fileMetrics)
+		return
+	}
 
-	relPath, _ := filepath.Rel(rootPath, result.path)
-	aggregation.dirStatsMap[topLevelDir(relPath)] += result.fileMetrics.Lines
+	// embedded languages are part of the codebase and directory totals, and are reported both nested
+	// under their parent language and rolled up into their own language
+	aggregation.codebaseStats.TotalFiles += total.Files
+	aggregation.codebaseStats.TotalCode += total.Code
+	aggregation.codebaseStats.TotalComments += total.Comments
+	aggregation.codebaseStats.TotalBlanks += total.Blanks
+	aggregation.codebaseStats.TotalDocs += total.Docs
+	aggregation.codebaseStats.TotalDocComments += total.DocComments
+	aggregation.codebaseStats.TotalMixed += total.Mixed
+	aggregation.codebaseStats.TotalLines += total.Lines
+
+	for i := range result.annMetrics.Annotations {
+		result.annMetrics.Annotations[i].Path = relPath
+	}
+	aggregation.annotationStats.add(result.annMetrics)
+	if result.cellLines {
+		aggregation.cellLinePaths[relPath] = true
+	}
 
-	stats := aggregation.langStatsMap[result.fileMetrics.Language]
+	addLanguageMetrics(aggregation.dirStatsMap, topLevelDir(relPath), total)
+	aggregation.testStats.add(fileReport.Class, relPath, result.fileMetrics)
+	addLanguageMetrics(aggregation.langStatsMap, result.fileMetrics.Language, result.fileMetrics)
+	for _, child := range result.fileMetrics.Children {
+		addLanguageMetrics(aggregation.langStatsMap, child.Language, child)
+	}
+
+	aggregation.topFilesList = append(aggregation.topFilesList, fileReport)
+	if result.duplicates != nil {
+		aggregation.duplicates.add(relPath, result.duplicates)
+	}
+	if result.goFile != nil {
+		aggregation.goStats.addGoFile(relPath, *result.goFile, aggregation.goPackages)
+	}
+	aggregation.progress.fileCounted(result.path, result.fileMetrics)
+}
+
+// addLanguageMetrics adds the counts of a single file to the running totals stored under key.
+func addLanguageMetrics(statsMap map[string]*LanguageMetrics, key string, metrics LanguageMetrics) {
+	stats := statsMap[key]
 	if stats == nil {
-		stats = &LanguageMetrics{Language: result.fileMetrics.Language}
-		aggregation.langStatsMap[result.fileMetrics.Language] = stats
-	}
-	stats.Files++
-	stats.Code += result.fileMetrics.Code
-	stats.Comments += result.fileMetrics.Comments
-	stats.Blanks += result.fileMetrics.Blanks
-	stats.Lines += result.fileMetrics.Lines
-
-	aggregation.topFilesList = append(aggregation.topFilesList, FileMetricsReport{
-		Metrics: result.fileMetrics,
-		Path:    relPath,
-	})
+		stats = &LanguageMetrics{Language: metrics.Language}
+		statsMap[key] = stats
+	}
+	stats.add(metrics)
 }
 
-func walkCodebase(flags Config, locJobs chan<- scanJob, depJobs chan<- DependencyFile) (int, error) {
+func walkCodebase(ctx context.Context, flags Config, languages *languageRegistry, locJobs chan<- scanJob, depJobs chan<- DependencyFile, progress *progressReporter) (int, []ScanError, error) {
 	totalDirs := 0
+	var walkErrors []ScanError
+
+	filter := newPathFilter(flags)
+	var ignores *ignoreMatcher
+	if !flags.NoIgnoreFlag {
+		ignores = newIgnoreMatcher(flags.PathFlag)
+	}
+
 	err := filepath.WalkDir(flags.PathFlag, func(path string, entry fs.DirEntry, walkErr error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if walkErr != nil {
+			// an unreadable root means there is nothing to scan at all
+			if path == flags.PathFlag || flags.FailFastFlag {
+				return walkErr
+			}
+			scanErr := newScanError(flags.PathFlag, path, walkErr)
+			walkErrors = append(walkErrors, scanErr)
+			progress.scanError(scanErr)
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -226,7 +412,9 @@ func walkCodebase(flags Config, locJobs chan<- scanJob, depJobs chan<- Dependenc
 		}
 
 		name := entry.Name()
-		if excludeFile(name) {
+		relPath, _ := filepath.Rel(flags.PathFlag, path)
+		relPath = filepath.ToSlash(relPath)
+		if !entry.IsDir() && filter.skipFile(relPath, name) {
 			return nil
 		}
 		if !flags.HiddenFlag && strings.HasPrefix(name, ".") {
@@ -235,25 +423,35 @@ func walkCodebase(flags Config, locJobs chan<- scanJob, depJobs chan<- Dependenc
 			}
 			return nil
 		}
+		if ignores != nil && path != flags.PathFlag && ignores.ignored(path, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if entry.IsDir() {
-			if excludeDir(name) {
+			if path != flags.PathFlag && filter.skipDir(relPath, name) {
 				return filepath.SkipDir
 			}
+			if ignores != nil {
+				ignores.enterDir(path)
+			}
 			totalDirs++
+			progress.dirEntered(path)
 			return nil
 		}
 		if isBinary(name) {
 			return nil
 		}
 
-		queueScanJob(path, name, locJobs)
+		queueScanJob(ctx, languages, path, name, locJobs)
 		if flags.DependencyFlag {
-			queueDependencyJob(path, name, depJobs)
+			queueDependencyJob(ctx, path, name, depJobs)
 		}
 		return nil
 	})
 
-	return totalDirs, err
+	return totalDirs, walkErrors, err
 }
 
 func shouldSkipForDepth(flags Config, path string, entry fs.DirEntry) bool {
@@ -269,17 +467,32 @@ func shouldSkipForDepth(flags Config, path string, entry fs.DirEntry) bool {
 	return depth > flags.MaxDepthFlag
 }
 
-func queueScanJob(path, name string, jobs chan<- scanJob) {
-	ext := hasNoExt(name)
-	if ext == "" {
-		return
+func queueScanJob(ctx context.Context, languages *languageRegistry, path, name string, jobs chan<- scanJob) {
+	job := scanJob{path: path}
+
+	if langDefinition := languages.determineLangByFilename(name); langDefinition != nil {
+		job.langDef = langDefinition
+	} else if ext := hasNoExt(name); ext == "" {
+		job.sniff = true
+	} else {
+		candidates := languages.determineLangByExt(ext)
+		switch len(candidates) {
+		case 0:
+			return
+		case 1:
+			job.langDef = candidates[0]
+		default:
+			job.candidates = candidates
+		}
 	}
-	if langDefinition := determineLangByExt(ext); langDefinition != nil {
-		jobs <- scanJob{path: path, langDef: langDefinition}
+
+	select {
+	case jobs <- job:
+	case <-ctx.Done():
 	}
 }
 
-func queueDependencyJob(path, name string, jobs chan<- DependencyFile) {
+func queueDependencyJob(ctx context.Context, path, name string, jobs chan<- DependencyFile) {
 	var dependencyType string
 	switch {
 	case name == "go.mod":
@@ -295,18 +508,20 @@ func queueDependencyJob(path, name string, jobs chan<- DependencyFile) {
 	}
 
 	if dependencyType != "" {
-		jobs <- DependencyFile{Path: path, Type: dependencyType}
+		select {
+		case jobs <- DependencyFile{Path: path, Type: dependencyType}:
+		case <-ctx.Done():
+		}
 	}
 }
 
 func buildCodebaseReport(flags Config, startTime time.Time, workers []*WorkerStats, aggregation *scanAggregation) CodebaseReport {
-	aggregation.codebaseStats.TotalLines = aggregation.codebaseStats.TotalCode + aggregation.codebaseStats.TotalComments + aggregation.codebaseStats.TotalBlanks
 	languageStats := buildLanguageStats(aggregation.langStatsMap, aggregation.codebaseStats.TotalLines)
 	dirStats := buildDirectoryStats(aggregation.dirStatsMap, aggregation.codebaseStats.TotalLines)
 	aggregation.codebaseStats.TotalLanguages = len(languageStats)
 
 	sort.Slice(aggregation.topFilesList, func(i, j int) bool {
-		return aggregation.topFilesList[i].Metrics.Lines > aggregation.topFilesList[j].Metrics.Lines
+		return aggregation.topFilesList[i].Metrics.Total().Lines > aggregation.topFilesList[j].Metrics.Total().Lines
 	})
 	sort.Slice(languageStats, func(i, j int) bool {
 		return languageStats[i].Percentage > languageStats[j].Percentage
@@ -314,14 +529,41 @@ func buildCodebaseReport(flags Config, startTime time.Time, workers []*WorkerSta
 	sort.Slice(dirStats, func(i, j int) bool {
 		return dirStats[i].Percentage > dirStats[j].Percentage
 	})
+	sort.Slice(aggregation.errors, func(i, j int) bool {
+		return aggregation.errors[i].Path < aggregation.errors[j].Path
+	})
+	sort.Slice(aggregation.generatedStats.Files, func(i, j int) bool {
+		return aggregation.generatedStats.Files[i].Path < aggregation.generatedStats.Files[j].Path
+	})
+	sortGoFunctions(aggregation.goStats.Functions)
+	annotations := aggregation.annotationStats.Annotations
+	sort.Slice(annotations, func(i, j int) bool {
+		if annotations[i].Path != annotations[j].Path {
+			return annotations[i].Path < annotations[j].Path
+		}
+		return annotations[i].Line < annotations[j].Line
+	})
+	if flags.BlameAnnotationsFlag {
+		sortByAge(annotations)
+	}
 
 	report := CodebaseReport{
 		LanguageMetrics:   languageStats,
 		FileMetrics:       aggregation.topFilesList,
 		DirMetrics:        dirStats,
+		ClassMetrics:      buildClassStats(aggregation.classStatsMap),
+		TestMetrics:       aggregation.testStats.build(),
 		CodebaseMetrics:   aggregation.codebaseStats,
 		AnnotationMetrics: aggregation.annotationStats,
+		GeneratedMetrics:  aggregation.generatedStats,
+		GoMetrics:         aggregation.goStats,
+		LongestFunctions:  longestFunctions(aggregation.topFilesList, longestFunctionsLimit),
+		DuplicateMetrics:  aggregation.duplicates.build(),
 		DependencyMetrics: aggregation.dependencyStats,
+		Errors:            aggregation.errors,
+	}
+	if flags.GitFlag {
+		report.GitMetrics = applyGitHistory(aggregation.gitHistory, report.FileMetrics)
 	}
 	if flags.ThroughputFlag {
 		totalTime := time.Since(startTime).Seconds()
@@ -357,21 +599,36 @@ func buildLanguageStats(statsMap map[string]*LanguageMetrics, totalLines int) []
 	stats := make([]LanguageMetricsReport, 0, len(statsMap))
 	for _, metrics := range statsMap {
 		stats = append(stats, LanguageMetricsReport{
-			Percentage: (float64(metrics.Code) / float64(totalLines)) * 100,
-			Metrics:    *metrics,
+			Percentage:  (float64(metrics.Code) / float64(totalLines)) * 100,
+			DocCoverage: docCoverage(*metrics),
+			Metrics:     *metrics,
 		})
 	}
 	return stats
 }
 
-func buildDirectoryStats(statsMap map[string]int, totalLines int) []DirMetricsReport {
+func buildDirectoryStats(statsMap map[string]*LanguageMetrics, totalLines int) []DirMetricsReport {
 	stats := make([]DirMetricsReport, 0, len(statsMap))
-	for directory, lines := range statsMap {
+	for directory, metrics := range statsMap {
 		stats = append(stats, DirMetricsReport{
-			Directory:  directory,
-			Percentage: (float64(lines) / float64(totalLines)) * 100,
-			Lines:      lines,
+			Directory:   directory,
+			Percentage:  (float64(metrics.Lines) / float64(totalLines)) * 100,
+			Lines:       metrics.Lines,
+			Code:        metrics.Code,
+			DocComments: metrics.DocComments,
+			DocCoverage: docCoverage(*metrics),
+			Complexity:  metrics.Complexity,
+			MaxNesting:  metrics.MaxNesting,
+			LongestLine: metrics.LongestLine,
 		})
 	}
 	return stats
 }
+
+// docCoverage is the share of documentation among code and documentation lines, as a percentage.
+func docCoverage(metrics LanguageMetrics) float64 {
+	if metrics.Code+metrics.DocComments == 0 {
+		return 0
+	}
+	return float64(metrics.DocComments) / float64(metrics.Code+metrics.DocComments) * 100
+}
@@ -1,6 +1,10 @@
 package pathfinder
 
 import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -65,3 +69,97 @@ func TestBuildCodebaseReportPerformanceMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateScanResultCollectsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		failFast bool
+		wantKind ScanErrorKind
+	}{
+		{name: "permission denied", err: &fs.PathError{Op: "open", Path: "/root/a.go", Err: fs.ErrPermission}, wantKind: ErrorKindPermission},
+		{name: "vanished", err: &fs.PathError{Op: "open", Path: "/root/a.go", Err: fs.ErrNotExist}, wantKind: ErrorKindVanished},
+		{name: "read error", err: errors.New("input/output error"), wantKind: ErrorKindRead},
+		{name: "fail fast", err: errors.New("input/output error"), failFast: true, wantKind: ErrorKindRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := Config{PathFlag: "/root", FailFastFlag: tt.failFast}
+			aggregation := newScanAggregation()
+
+			aggregateScanResult(flags, scanResult{path: "/root/pkg/a.go", err: tt.err}, aggregation)
+
+			if len(aggregation.errors) != 1 {
+				t.Fatalf("errors = %+v, want exactly one", aggregation.errors)
+			}
+			got := aggregation.errors[0]
+			if got.Kind != tt.wantKind || got.Path != filepath.Join("pkg", "a.go") {
+				t.Fatalf("error = %+v, want kind %q for pkg/a.go", got, tt.wantKind)
+			}
+			if aggregation.codebaseStats.TotalFiles != 0 || len(aggregation.topFilesList) != 0 {
+				t.Fatalf("failed file was counted: %+v", aggregation.codebaseStats)
+			}
+			if (aggregation.firstErr != nil) != tt.failFast {
+				t.Fatalf("firstErr = %v, want set only in fail-fast mode", aggregation.firstErr)
+			}
+		})
+	}
+}
+
+func TestScanContextCanceled(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"main.go":     "package main\n",
+		"pkg/util.go": "package pkg\n",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := ScanContext(ctx, Config{PathFlag: root, RecursiveFlag: true, DependencyFlag: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ScanContext() error = %v, want context.Canceled", err)
+	}
+	if !report.Incomplete {
+		t.Fatal("report.Incomplete = false, want true for a canceled scan")
+	}
+	if report.CodebaseMetrics.TotalFiles != 0 {
+		t.Fatalf("TotalFiles = %d, want 0 when canceled before the walk", report.CodebaseMetrics.TotalFiles)
+	}
+}
+
+func TestScanEmitsProgressEvents(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"main.go":      "package main\n\nfunc main() {}\n",
+		"pkg/util.go":  "package pkg\n",
+		"package.json": `{"dependencies": {"left-pad": "1.0.0"}}`,
+	})
+
+	var events []ProgressEvent
+	_, err := Scan(Config{
+		PathFlag:       root,
+		RecursiveFlag:  true,
+		DependencyFlag: true,
+		OnProgress: func(event ProgressEvent) {
+			events = append(events, event)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	kinds := map[ProgressEventKind]int{}
+	for _, event := range events {
+		kinds[event.Kind]++
+	}
+	if kinds[ProgressFileCounted] != 3 || kinds[ProgressDirEntered] != 2 || kinds[ProgressDependencyParsed] != 1 {
+		t.Fatalf("event kinds = %v, want 3 files, 2 dirs and 1 dependency", kinds)
+	}
+
+	last := events[len(events)-1]
+	if last.FilesCounted != 3 || last.DirsEntered != 2 || last.LinesCounted != 5 {
+		t.Fatalf("final totals = %d files, %d dirs, %d lines, want 3, 2, 5", last.FilesCounted, last.DirsEntered, last.LinesCounted)
+	}
+}
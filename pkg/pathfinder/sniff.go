@@ -0,0 +1,101 @@
+package pathfinder
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// headSniffSize is how much of a file is peeked at before counting it, to tell its encoding and
+// whether it's binary or generated.
+const headSniffSize = 4096
+
+// errBinaryContent is returned by fileCounter for files whose content turns out to be binary.
+// They are skipped rather than reported as errors.
+var errBinaryContent = errors.New("binary content")
+
+// generated reasons, see FileMetricsReport.Generated
+const (
+	generatedMarker   = "marker"
+	generatedMinified = "minified"
+	generatedLockfile = "lockfile"
+)
+
+var (
+	// generatedHeader matches the conventional "this file is generated" comments, like Go's
+	// "Code generated ... DO NOT EDIT.", "@generated" and C#'s "<auto-generated>", at the start of a line.
+	generatedHeader = regexp.MustCompile(`(?im)^[ \t]*(?://+|#+|/\*+|\*|--|;+|<!--|\{-|\(\*)?[ \t]*` +
+		`(?:code generated\b.*\bdo not edit\b|@generated\b|<auto-generated\b|` +
+		`(?:auto-?generated|automatically generated|this file (?:is|was) generated)\b.*\bdo not (?:edit|modify)\b)`)
+
+	// lockfiles only lists names with a catalog language, as files without one are never counted.
+	// package-lock.json is a default exclude, so it's only seen with NoDefaultExcludesFlag.
+	lockfiles = map[string]struct{}{
+		"package-lock.json":   {},
+		"npm-shrinkwrap.json": {},
+		"pnpm-lock.yaml":      {},
+		"packages.lock.json":  {},
+	}
+)
+
+// minifiedLineLength is the average line length at the start of a file above which it's taken as minified.
+const minifiedLineLength = 500
+
+// peekHead returns the start of br without consuming it.
+func peekHead(br *bufio.Reader) ([]byte, error) {
+	head, err := br.Peek(min(headSniffSize, br.Size()))
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	return head, nil
+}
+
+// looksBinary reports whether the start of a file is binary rather than text: it has a NUL byte
+// (outside of UTF-16 or UTF-32 text), or too many control characters and invalid UTF-8 sequences
+// to be text in any encoding we read.
+func looksBinary(head []byte) bool {
+	switch enc, _ := detectEncoding(head); enc {
+	case EncodingUTF16LE, EncodingUTF16BE, EncodingUTF32LE, EncodingUTF32BE:
+		return false
+	}
+	if bytes.IndexByte(head, 0) != -1 {
+		return true
+	}
+
+	suspicious := 0
+	for i := 0; i < len(head); {
+		r, size := utf8.DecodeRune(head[i:])
+		switch {
+		case r == utf8.RuneError && size == 1 && len(head)-i >= utf8.UTFMax:
+			suspicious++ // not counted at the very end, where the head can cut a character in half
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != '\v' && r != 0x1b, r == 0x7f:
+			suspicious++ // control characters other than whitespace and terminal escapes
+		}
+		i += size
+	}
+	return suspicious*10 > len(head)*3
+}
+
+// generatedReason returns why a file looks generated from its name and the start of its (decoded)
+// content, or "" if it looks hand-written.
+func generatedReason(name string, head []byte) string {
+	if _, ok := lockfiles[name]; ok {
+		return generatedLockfile
+	}
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".min.js") || strings.HasSuffix(lower, ".min.css") || strings.HasSuffix(lower, ".min.mjs") {
+		return generatedMinified
+	}
+	if generatedHeader.Match(head) {
+		return generatedMarker
+	}
+	// minified code puts kilobytes on a single line; a short file can't be told apart from a long one-liner
+	if len(head) >= 1024 && len(head)/(bytes.Count(head, []byte("\n"))+1) > minifiedLineLength {
+		return generatedMinified
+	}
+	return ""
+}
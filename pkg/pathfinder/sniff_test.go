@@ -0,0 +1,112 @@
+package pathfinder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want bool
+	}{
+		{name: "source", head: "package main\n\nfunc main() {}\n", want: false},
+		{name: "windows-1252", head: "// caf\xe9 na\xefve r\xe9sum\xe9\nint x = 1;\n", want: false},
+		{name: "terminal escapes", head: "\x1b[31mred\x1b[0m\n", want: false},
+		{name: "nul byte", head: "\x7fELF\x02\x01\x01\x00\x00", want: true},
+		{name: "compressed", head: "\x8b\xe3\x91\xc4\x02\xff\x9a\x81\xfe\x13\x8d\xc7\x05\x9e\xa4\xf0", want: true},
+		{name: "utf-16le", head: "a\x00b\x00\n\x00", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksBinary([]byte(tt.head)); got != tt.want {
+				t.Fatalf("looksBinary(%q) = %v, want %v", tt.head, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratedReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		head     string
+		want     string
+	}{
+		{name: "hand-written", filename: "main.go", head: "package main\n\n// generated code goes elsewhere\n"},
+		{name: "go marker", filename: "api.pb.go", head: "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", want: "marker"},
+		{name: "@generated", filename: "schema.js", head: "/**\n * @generated SignedSource<<abc>>\n */\n", want: "marker"},
+		{name: "c# auto-generated", filename: "Form.Designer.cs", head: "//------\n// <auto-generated>\n", want: "marker"},
+		{name: "marker in a string", filename: "gen.go", head: "const header = \"// Code generated by gen. DO NOT EDIT.\"\n"},
+		{name: "lockfile", filename: "pnpm-lock.yaml", head: "lockfileVersion: '9.0'\n", want: "lockfile"},
+		{name: "minified name", filename: "jquery.min.js", head: "!function(e){}\n", want: "minified"},
+		{name: "minified content", filename: "bundle.js", head: strings.Repeat("var a=1;", 300), want: "minified"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generatedReason(tt.filename, []byte(tt.head)); got != tt.want {
+				t.Fatalf("generatedReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanSeparatesGeneratedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"main.go":    "package main\n",
+		"api.pb.go":  "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n",
+		"module.pyc": "\x03\xf3\r\n\x00\x00\x00\x00",
+		"data.py":    "\x00\x01\x02binary\n",
+	})
+
+	report, err := Scan(Config{PathFlag: root})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if report.CodebaseMetrics.TotalFiles != 1 || report.CodebaseMetrics.TotalLines != 1 {
+		t.Fatalf("CodebaseMetrics = %+v, want only main.go", report.CodebaseMetrics)
+	}
+	generated := report.GeneratedMetrics
+	if generated.TotalFiles != 1 || generated.TotalLines != 3 || generated.Files[0].Path != "api.pb.go" || generated.Files[0].Generated != "marker" {
+		t.Fatalf("GeneratedMetrics = %+v, want api.pb.go with 3 lines", generated)
+	}
+
+	report, err = Scan(Config{PathFlag: root, IncludeGeneratedFlag: true})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if report.CodebaseMetrics.TotalFiles != 2 || report.GeneratedMetrics.TotalFiles != 0 {
+		t.Fatalf("CodebaseMetrics = %+v, GeneratedMetrics = %+v, want both Go files counted", report.CodebaseMetrics, report.GeneratedMetrics)
+	}
+}
+
+func TestScanSeparatesLockfiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"main.go":            "package main\n",
+		"pnpm-lock.yaml":     "lockfileVersion: '9.0'\n\nimporters: {}\n",
+		"packages.lock.json": "{\n  \"version\": 1\n}\n",
+		"Cargo.lock":         "version = 3\n",
+	})
+
+	report, err := Scan(Config{PathFlag: root})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if report.CodebaseMetrics.TotalFiles != 1 {
+		t.Fatalf("CodebaseMetrics = %+v, want only main.go", report.CodebaseMetrics)
+	}
+	generated := report.GeneratedMetrics
+	if generated.TotalFiles != 2 || generated.TotalLines != 6 {
+		t.Fatalf("GeneratedMetrics = %+v, want the two lockfiles with 6 lines", generated)
+	}
+	for i, want := range []string{"packages.lock.json", "pnpm-lock.yaml"} {
+		if file := generated.Files[i]; file.Path != want || file.Generated != "lockfile" {
+			t.Fatalf("GeneratedMetrics.Files[%d] = %s (%q), want %s (\"lockfile\")", i, file.Path, file.Generated, want)
+		}
+	}
+}
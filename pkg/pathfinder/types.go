@@ -14,6 +14,20 @@ type Config struct {
 	// specific values like 4KB, 8KB, 16KB is usually the best performance.
 	BufferSizeFlag int
 
+	// NoIgnoreFlag, if true, disables .gitignore, .ignore and .git/info/exclude handling during the walk.
+	NoIgnoreFlag bool
+
+	// ExcludeFlag lists doublestar globs (e.g. "**/*_generated.go", "docs/**") of files and directories to skip.
+	// Patterns are matched against slash separated paths relative to PathFlag; a pattern without a slash matches names at any depth.
+	ExcludeFlag []string
+
+	// IncludeFlag lists doublestar globs of files to scan. If set, files that match none of the patterns are skipped.
+	IncludeFlag []string
+
+	// NoDefaultExcludesFlag, if true, replaces the built-in exclusions (e.g. node_modules, vendor, go.sum) with ExcludeFlag
+	// instead of extending them.
+	NoDefaultExcludesFlag bool
+
 	// RecursiveFlag, if true, scans subdirectories recursively.
 	RecursiveFlag bool
 
@@ -32,49 +46,331 @@ type Config struct {
 
 	// ThroughputFlag, if true, shows throughput information without the detailed report.
 	ThroughputFlag bool
+
+	// FailFastFlag, if true, makes Scan return the first file or directory error instead of
+	// collecting it in CodebaseReport.Errors and continuing with the rest of the codebase.
+	FailFastFlag bool
+
+	// DocstringsAsCommentsFlag, if true, counts docstring lines as Comments instead of as a separate Docs count.
+	DocstringsAsCommentsFlag bool
+
+	// MixedLinesFlag decides where lines with both code and a comment (e.g. "x := 1 // set x") are counted.
+	// They are always counted in LanguageMetrics.Mixed as well. Defaults to MixedAsCode.
+	MixedLinesFlag MixedLineMode
+
+	// AnnotationTagsFlag lists the comment tags counted as annotations (e.g. "TODO", "XXX", "BUG", "NOTE").
+	// Tags are case-sensitive and only match as whole words, so "TODOList" isn't a TODO. Defaults to TODO, FIXME and HACK.
+	AnnotationTagsFlag []string
+
+	// BlameAnnotationsFlag, if true, runs git blame on every annotation line to fill in its author, commit and age,
+	// and sorts AnnotationMetrics.Annotations oldest first. The scanned path must be inside a local git repository.
+	BlameAnnotationsFlag bool
+
+	// GoMetricsFlag, if true, parses every Go file with go/parser to fill in CodebaseReport.GoMetrics
+	// (packages, functions, types, tests and per-function length and complexity).
+	GoMetricsFlag bool
+
+	// FunctionMetricsFlag, if true, finds the functions and methods of every file in a language with FunctionRules
+	// (e.g. Go, Python, JavaScript, TypeScript, Java) and reports their line span and parameter count in
+	// FileMetricsReport.Functions. It's slower than counting lines alone.
+	FunctionMetricsFlag bool
+
+	// DuplicatesFlag, if true, looks for code repeated across the codebase (or within a file) and reports identical
+	// files, duplicated blocks and the share of duplicated code in CodebaseReport.DuplicateMetrics.
+	DuplicatesFlag bool
+
+	// MinDuplicateLinesFlag is the number of code lines a block needs to be reported as a duplicate, not counting
+	// lines without letters or digits (e.g. "}"). Only applies if DuplicatesFlag is true. Defaults to 6.
+	MinDuplicateLinesFlag int
+
+	// IncludeVendoredFlag, if true, walks vendored directories (e.g. vendor, node_modules) even though they are
+	// default excludes. Their files are counted and classified as FileClassVendored.
+	IncludeVendoredFlag bool
+
+	// TestPatternsFlag lists doublestar globs (e.g. "**/*_test.go", "e2e/**") of test files, which are classified as
+	// FileClassTest and reported in CodebaseReport.TestMetrics. Patterns are matched like ExcludeFlag. Defaults to the
+	// conventions of common languages (e.g. _test.go, test_*.py, *.spec.ts, src/test/java, __tests__).
+	TestPatternsFlag []string
+
+	// IncludeGeneratedFlag, if true, counts generated files (with a "Code generated ... DO NOT EDIT." or "@generated"
+	// header, minified code and lockfiles) like any other file. By default they are left out of every total and
+	// reported in CodebaseReport.GeneratedMetrics instead.
+	IncludeGeneratedFlag bool
+
+	// Languages adds custom language definitions for this scan only, on top of the built-in catalog and
+	// the ones added with RegisterLanguage (see LoadLanguages to read them from a file).
+	// They must not claim the same extension, filename or interpreter as each other.
+	Languages []LanguageDefinition
+
+	// OnProgress, if set, is called with a ProgressEvent as the scan runs (directory entered, file counted, ...).
+	// Calls are serialized, so it doesn't need to be safe for concurrent use, but it blocks the pipeline and should return quickly.
+	OnProgress func(ProgressEvent)
+}
+
+// MixedLineMode is where Config.MixedLinesFlag counts lines with both code and a comment.
+type MixedLineMode string
+
+const (
+	MixedAsCode    MixedLineMode = "code"    // count mixed lines as Code only
+	MixedAsComment MixedLineMode = "comment" // count mixed lines as Comments only
+	MixedAsBoth    MixedLineMode = "both"    // count mixed lines as both Code and Comments, so Code + Comments can exceed Lines
+)
+
+// ProgressEventKind identifies what happened in a ProgressEvent.
+type ProgressEventKind string
+
+const (
+	ProgressDirEntered       ProgressEventKind = "dir entered"       // the walker descended into a directory
+	ProgressFileCounted      ProgressEventKind = "file counted"      // a file was read and its lines counted
+	ProgressDependencyParsed ProgressEventKind = "dependency parsed" // a dependency manifest was parsed
+	ProgressError            ProgressEventKind = "error"             // a file or directory could not be read
+)
+
+// ProgressEvent is emitted through Config.OnProgress while a scan is running.
+type ProgressEvent struct {
+	Kind       ProgressEventKind
+	Path       string          // Path relative to the scan root of the directory or file the event is about
+	Metrics    LanguageMetrics // Metrics of the counted file (only set for ProgressFileCounted)
+	Dependency DependencyFile  // The parsed manifest (only set for ProgressDependencyParsed)
+	Error      *ScanError      // The error that was hit (only set for ProgressError)
+
+	// running totals at the time of the event
+	DirsEntered  int // Directories entered so far
+	FilesCounted int // Files counted so far
+	LinesCounted int // Lines counted so far
+	Errors       int // Errors hit so far
 }
 
 // CommentType defines the comment syntax markers for a programming language.
 type CommentType struct {
-	SingleLine string // Prefix for single-line comments (e.g., "//" or "#")
-	BlockStart string // Start marker for block comments (e.g., "/*")
-	BlockEnd   string // End marker for block comments (e.g., "*/")
+	SingleLine  []string       `json:"line,omitempty" yaml:"line,omitempty"`                 // Prefixes for single-line comments (e.g., "//", or "//" and "#" for PHP)
+	Blocks      []BlockComment `json:"blocks,omitempty" yaml:"blocks,omitempty"`             // Block comment delimiters (e.g., "/*" and "*/")
+	Doc         []string       `json:"doc,omitempty" yaml:"doc,omitempty"`                   // Prefixes that make a comment a doc comment (e.g., "///" or "/**")
+	FirstColumn []string       `json:"first_column,omitempty" yaml:"first_column,omitempty"` // Prefixes that start a whole-line comment only at the start of a line, after any indentation (e.g., "*" in ABAP or "rem" in Batch)
+}
+
+// BlockComment defines a pair of block comment delimiters.
+type BlockComment struct {
+	Start  string `json:"start" yaml:"start"`                       // Start marker (e.g., "/*", "{-", "=begin")
+	End    string `json:"end" yaml:"end"`                           // End marker (e.g., "*/", "-}", "=end")
+	Nested bool   `json:"nested,omitempty" yaml:"nested,omitempty"` // Whether the comment nests, like Rust's "/* /* */ */" or Haskell's "{- {- -} -}"
+}
+
+// StringType defines a string, character or template literal, so comment markers inside it aren't counted as comments.
+type StringType struct {
+	Start     string `json:"start" yaml:"start"`                             // Opening delimiter (e.g., "\"", "`", "r#\"", "\"\"\"")
+	End       string `json:"end" yaml:"end"`                                 // Closing delimiter
+	Escape    string `json:"escape,omitempty" yaml:"escape,omitempty"`       // Escape prefix that skips the next character (e.g., "\\"), empty for raw strings
+	Multiline bool   `json:"multiline,omitempty" yaml:"multiline,omitempty"` // Whether the literal can span several lines
+	Doc       bool   `json:"doc,omitempty" yaml:"doc,omitempty"`             // Whether the literal is a docstring when it starts a line (e.g., Python's "\"\"\"")
 }
 
 // LanguageDefinition maps a programming language to its file extensions and comment syntax.
 type LanguageDefinition struct {
-	Name string      // The common name of the language (e.g., "Go", "Python")
-	Type CommentType // The comment syntax definition
-	Ext  []string    // List of file extensions (e.g., ".go", ".py")
+	Name         string         `json:"name" yaml:"name"`                                     // The common name of the language (e.g., "Go", "Python")
+	Type         CommentType    `json:"comments" yaml:"comments"`                             // The comment syntax definition
+	Strings      []StringType   `json:"strings,omitempty" yaml:"strings,omitempty"`           // String literal syntaxes, matched longest delimiter first
+	DocBefore    string         `json:"doc_before,omitempty" yaml:"doc_before,omitempty"`     // Regexp of declarations whose directly preceding comments are doc comments (e.g., Go's exported funcs)
+	Complexity   []string       `json:"complexity,omitempty" yaml:"complexity,omitempty"`     // Branch keywords and operators counted in code for LanguageMetrics.Complexity (e.g., "if", "case", "&&")
+	Functions    *FunctionRules `json:"functions,omitempty" yaml:"functions,omitempty"`       // How to find function declarations, for FunctionMetricsFlag
+	Embedded     EmbedMode      `json:"embedded,omitempty" yaml:"embedded,omitempty"`         // How other languages are embedded in files of this language (e.g., "html" for <script> and <style> blocks)
+	Ext          []string       `json:"extensions,omitempty" yaml:"extensions,omitempty"`     // List of file extensions (e.g., ".go", ".py")
+	Filenames    []string       `json:"filenames,omitempty" yaml:"filenames,omitempty"`       // Exact file names, matched before extensions (e.g., "Makefile", "Dockerfile")
+	Interpreters []string       `json:"interpreters,omitempty" yaml:"interpreters,omitempty"` // Shebang interpreters used to detect extensionless scripts (e.g., "python3", "bash")
+}
+
+// FunctionRules find the function declarations of a language for FileMetricsReport.Functions.
+type FunctionRules struct {
+	// Patterns are regexps matched against the code of each line, without comments, string literals or indentation.
+	// The "name" group is the function name, and the first parenthesized list after it holds the parameters.
+	Patterns []string `json:"patterns" yaml:"patterns"`
+
+	// Indent, if true, means function bodies are the lines indented deeper than the declaration (e.g. Python)
+	// instead of a brace block.
+	Indent bool `json:"indent,omitempty" yaml:"indent,omitempty"`
 }
 
 // LanguageMetrics contains the raw counts for a specific language.
 type LanguageMetrics struct {
-	Language string // Name of the language
-	Files    int    // Number of files detected
-	Code     int    // Lines of actual code
-	Comments int    // Lines of comments
-	Blanks   int    // Empty lines
-	Lines    int    // Total lines (Code + Comments + Blanks)
+	Language    string // Name of the language
+	Files       int    // Number of files detected
+	Code        int    // Lines of actual code
+	Comments    int    // Lines of comments
+	Blanks      int    // Empty lines
+	Docs        int    // Lines of docstrings (e.g. Python's """...""" on their own lines), counted as Comments instead when DocstringsAsCommentsFlag is set
+	DocComments int    // Lines of documentation (doc comments like "///" or "/** */" and docstrings), a subset of Comments and Docs
+	Mixed       int    // Lines with both code and a comment, also counted as Code and/or Comments depending on Config.MixedLinesFlag
+	Lines       int    // Total physical lines (Code + Comments + Blanks + Docs, unless mixed lines are counted as both)
+	Complexity  int    // Approximate cyclomatic complexity: branch keywords and operators (LanguageDefinition.Complexity) in code
+	MaxNesting  int    // Deepest brace nesting in code, or indentation depth for languages without braces (a maximum, not a sum)
+	LongestLine int    // Length of the longest line in characters (a maximum, not a sum)
+
+	// Children holds the languages embedded in this one (e.g. JavaScript in HTML <script> blocks), sorted by name.
+	// Their lines aren't part of the counts above, Total adds them in. Embedded languages don't count as Files.
+	Children []LanguageMetrics
 }
 
-// AnnotationMetrics tracks special comment tags like TODO, FIXME, and HACK.
+// EmbedMode is how other languages are embedded in files of a language, set in LanguageDefinition.Embedded.
+type EmbedMode string
+
+const (
+	EmbedHTML     EmbedMode = "html"     // <script> and <style> blocks, picked by their lang or type attribute (e.g. HTML, Vue, Svelte)
+	EmbedMarkdown EmbedMode = "markdown" // fenced code blocks, picked by their info string (e.g. "```go")
+	EmbedNotebook EmbedMode = "notebook" // Jupyter notebook cells, code cells in the kernel language and markdown cells as Markdown
+)
+
+// AnnotationMetrics tracks special comment tags like TODO, FIXME, and HACK (see Config.AnnotationTagsFlag).
 type AnnotationMetrics struct {
-	TotalTODO        int // Count of "TODO" tags
-	TotalFIXME       int // Count of "FIXME" tags
-	TotalHACK        int // Count of "HACK" tags
-	TotalAnnotations int // Sum of all annotation types
+	TotalTODO        int            // Count of "TODO" tags
+	TotalFIXME       int            // Count of "FIXME" tags
+	TotalHACK        int            // Count of "HACK" tags
+	TotalAnnotations int            // Sum of all annotation types
+	Tags             map[string]int // Count of each tag found, including the three above
+	Annotations      []Annotation   // Every annotation found, sorted by path and line (or oldest first when blamed)
+}
+
+// Annotation is a single tagged comment, like "// TODO(alice): retry on timeout".
+type Annotation struct {
+	Path  string // Path relative to the scan root of the file it's in
+	Line  int    // Line number, starting at 1 (within the cell for Jupyter notebooks)
+	Tag   string // The tag that matched (e.g. "TODO")
+	Owner string // The owner in "TODO(owner)", if any
+	Text  string // The comment text following the tag
+
+	// set when Config.BlameAnnotationsFlag is true and the line is committed
+	Author  string    // Author of the commit that last changed the line
+	Commit  string    // Hash of that commit
+	Date    time.Time // Author date of that commit
+	AgeDays int       // Days between Date and the scan
 }
 
 // CodebaseMetrics aggregates statistics for the entire scanned project.
 type CodebaseMetrics struct {
-	TotalFiles     int // Total files scanned
-	TotalDirs      int // Total directories encountered
-	TotalLanguages int // Number of distinct languages detected
-	TotalCode      int // Total lines of code across all languages
-	TotalComments  int // Total lines of comments across all languages
-	TotalBlanks    int // Total blank lines across all languages
-	TotalLines     int // Grand total of all lines
+	TotalFiles       int // Total files scanned
+	TotalDirs        int // Total directories encountered
+	TotalLanguages   int // Number of distinct languages detected
+	TotalCode        int // Total lines of code across all languages
+	TotalComments    int // Total lines of comments across all languages
+	TotalBlanks      int // Total blank lines across all languages
+	TotalDocs        int // Total docstring lines across all languages (0 when DocstringsAsCommentsFlag is set)
+	TotalDocComments int // Total documentation lines across all languages, a subset of TotalComments and TotalDocs
+	TotalMixed       int // Total lines with both code and a comment across all languages
+	TotalLines       int // Grand total of all lines
+}
+
+// FunctionMetrics contains the span of a function or method found with FunctionRules.
+type FunctionMetrics struct {
+	Path      string // Relative path to the file
+	Name      string // Function or method name
+	StartLine int    // Line the declaration starts on
+	EndLine   int    // Line the body ends on
+	Lines     int    // Lines from StartLine to EndLine
+	Params    int    // Number of parameters
+}
+
+// FileClass is what kind of file a counted file is, to tell the code a repository owns from the rest.
+type FileClass string
+
+const (
+	FileClassFirstParty FileClass = "first-party"   // Hand-written code that isn't any of the classes below
+	FileClassVendored   FileClass = "vendored"      // Anything under a vendored directory (e.g. vendor, node_modules, third_party)
+	FileClassGenerated  FileClass = "generated"     // Generated files, see FileMetricsReport.Generated
+	FileClassTest       FileClass = "test"          // Tests and test fixtures, see Config.TestPatternsFlag
+	FileClassDocs       FileClass = "documentation" // Prose languages (e.g. Markdown) and anything under a docs directory
+)
+
+// ClassMetricsReport contains the totals of one FileClass.
+type ClassMetricsReport struct {
+	Class      FileClass // The class these totals are for
+	Percentage float64   // Percentage of the lines of every class, generated files included
+	Files      int       // Files in this class
+	Lines      int       // Total lines in this class, including embedded languages
+	Code       int       // Lines of code in this class
+}
+
+// TestMetrics contains the split between production (first-party) and test code, see Config.TestPatternsFlag.
+type TestMetrics struct {
+	Files       int               // First-party files
+	TestFiles   int               // Test files
+	Code        int               // Lines of code in first-party files
+	TestCode    int               // Lines of code in test files
+	Ratio       float64           // Test code lines per line of first-party code
+	Languages   []TestSplitReport // Split per language, with embedded languages counted on their own, most code first
+	Directories []TestSplitReport // Split per top-level directory, most code first
+}
+
+// TestSplitReport contains the production and test code of one language or top-level directory.
+type TestSplitReport struct {
+	Name      string  // Language or top-level directory
+	Files     int     // First-party files
+	TestFiles int     // Test files
+	Code      int     // Lines of code in first-party files
+	TestCode  int     // Lines of code in test files
+	Ratio     float64 // Test code lines per line of first-party code, 0 if there is none
+}
+
+// DuplicateMetrics contains the code repeated across a codebase (only set if DuplicatesFlag is true).
+// Each file is compared with the files before it in path order, so the copy with the first path isn't counted as duplicated.
+type DuplicateMetrics struct {
+	Files           int                  // Files compared
+	Lines           int                  // Code lines compared, leaving out lines without letters or digits (e.g. "}")
+	DuplicatedLines int                  // Lines that are a copy of code found elsewhere
+	Percentage      float64              // DuplicatedLines as a percentage of Lines
+	DuplicateFiles  []DuplicateFileGroup // Files with the same code, most duplicated lines first
+	Blocks          []DuplicateBlock     // The 100 longest duplicated blocks between and within files, longest first
+	Partial         bool                 // True if the codebase has more code lines than the index holds, so the files last in path order were left out
+}
+
+// DuplicateFileGroup is a group of files with the same code once whitespace, comments and string literals are left out.
+type DuplicateFileGroup struct {
+	Lines int      // Code lines in each file
+	Paths []string // Relative paths of the files, sorted
+}
+
+// DuplicateBlock is a block of code that appears in two places.
+type DuplicateBlock struct {
+	Lines     int                 // Code lines in the block
+	Locations []DuplicateLocation // Both copies, sorted by path and line
+}
+
+// DuplicateLocation is where one copy of a DuplicateBlock is.
+type DuplicateLocation struct {
+	Path      string // Relative path to the file
+	StartLine int    // Line the copy starts on
+	EndLine   int    // Line the copy ends on
+}
+
+// GoMetrics contains what go/parser finds in the Go files of a codebase (only set if GoMetricsFlag is true).
+type GoMetrics struct {
+	Packages        int                 // Distinct packages, by directory and package name (so foo and foo_test are two)
+	Files           int                 // Go files parsed
+	ParseErrors     int                 // Go files that couldn't be parsed, left out of every other count
+	ExportedFuncs   int                 // Exported top-level functions, methods not included
+	UnexportedFuncs int                 // Unexported top-level functions, methods not included
+	Methods         int                 // Functions with a receiver
+	Types           int                 // Type declarations, interfaces included
+	Interfaces      int                 // Interface type declarations
+	TestFuncs       int                 // TestXxx functions in _test.go files
+	Benchmarks      int                 // BenchmarkXxx functions in _test.go files
+	Functions       []GoFunctionMetrics // Every function and method, most complex first
+}
+
+// GoFunctionMetrics contains the length and complexity of a Go function or method.
+type GoFunctionMetrics struct {
+	Path       string // Relative path to the file
+	Name       string // Function name, with the receiver for methods (e.g. "(*Scanner).Scan")
+	Line       int    // Line of the func keyword
+	Lines      int    // Lines from the func keyword to the closing brace
+	Complexity int    // Cyclomatic complexity: 1 plus every if, for, non-default case, && and ||
+	Exported   bool   // Whether the function or method is exported
+}
+
+// GeneratedMetrics is the separate bucket generated files are counted in, unless IncludeGeneratedFlag is set.
+type GeneratedMetrics struct {
+	TotalFiles int                 // Generated files found
+	TotalCode  int                 // Lines of code in generated files
+	TotalLines int                 // Lines in generated files, including embedded languages
+	Files      []FileMetricsReport // The generated files, sorted by path
 }
 
 // DependencyFile represents a manifest file found in the project (e.g., go.mod).
@@ -90,23 +386,65 @@ type DependencyMetrics struct {
 	DependencyFiles   []DependencyFile // List of files that were parsed for dependencies
 }
 
+// GitContributor describes a single commit author found in the git history.
+type GitContributor struct {
+	Name    string // Author name as recorded in the most recent commit seen
+	Email   string // Author email, used to identify the contributor
+	Commits int    // Number of commits authored
+}
+
+// GitMetrics summarizes the git history of the scanned path.
+type GitMetrics struct {
+	TotalCommits      int                 // Number of non-merge commits touching the scanned path
+	TotalContributors int                 // Number of distinct commit authors
+	FirstCommit       time.Time           // Date of the oldest commit
+	LastCommit        time.Time           // Date of the newest commit
+	Contributors      []GitContributor    // Authors sorted by number of commits
+	Hotspots          []FileMetricsReport // Most frequently changed scanned files (churn hotspots)
+}
+
 // FileMetricsReport contains metrics for a single file.
 type FileMetricsReport struct {
-	Path    string          // Relative path to the file
-	Metrics LanguageMetrics // The metrics calculated for this file
+	Path      string            // Relative path to the file
+	Metrics   LanguageMetrics   // The metrics calculated for this file
+	Encoding  Encoding          // Detected text encoding of the file, which is transcoded to UTF-8 before counting
+	Generated string            // Why the file looks generated: "marker", "minified" or "lockfile". Empty for hand-written files
+	Class     FileClass         // Whether the file is first-party code, vendored, generated, a test or documentation
+	Functions []FunctionMetrics // Functions and methods in the order they start (only set if FunctionMetricsFlag is true)
+	Commits   int               // Number of commits that touched this file (only set if GitFlag is true)
 }
 
+// Encoding is the text encoding detected for a file, from its byte order mark or content.
+type Encoding string
+
+const (
+	EncodingUTF8        Encoding = "UTF-8"
+	EncodingUTF8BOM     Encoding = "UTF-8 BOM"    // UTF-8 with a byte order mark, which is stripped before counting
+	EncodingUTF16LE     Encoding = "UTF-16LE"     // with or without a byte order mark
+	EncodingUTF16BE     Encoding = "UTF-16BE"     // with or without a byte order mark
+	EncodingUTF32LE     Encoding = "UTF-32LE"     // only detected from its byte order mark
+	EncodingUTF32BE     Encoding = "UTF-32BE"     // only detected from its byte order mark
+	EncodingWindows1252 Encoding = "Windows-1252" // text that isn't valid UTF-8 is assumed to be Windows-1252 (a superset of Latin-1)
+)
+
 // DirMetricsReport contains metrics for a specific directory.
 type DirMetricsReport struct {
-	Directory  string  // Path to the directory
-	Percentage float64 // Percentage of the codebase contained in this directory
-	Lines      int     // Total lines in this directory
+	Directory   string  // Path to the directory
+	Percentage  float64 // Percentage of the codebase contained in this directory
+	Lines       int     // Total lines in this directory
+	Code        int     // Lines of code in this directory
+	DocComments int     // Lines of documentation in this directory
+	DocCoverage float64 // Percentage of documentation among code and documentation lines
+	Complexity  int     // Approximate cyclomatic complexity of the files in this directory
+	MaxNesting  int     // Deepest nesting in this directory
+	LongestLine int     // Length of the longest line in this directory
 }
 
 // LanguageMetricsReport wraps LanguageMetrics with a percentage relative to the whole codebase.
 type LanguageMetricsReport struct {
-	Percentage float64         // Percentage of the codebase written in this language
-	Metrics    LanguageMetrics // The raw metrics
+	Percentage  float64         // Percentage of the codebase written in this language
+	DocCoverage float64         // Percentage of documentation among code and documentation lines (DocComments / (Code + DocComments))
+	Metrics     LanguageMetrics // The raw metrics
 }
 
 // WorkerStats for tracking worker stats if throughput flag is enabled
@@ -134,13 +472,38 @@ type PerformanceMetrics struct {
 	OverallThroughput  float64        // Files processed per second
 }
 
+// ScanErrorKind classifies why a file or directory could not be scanned.
+type ScanErrorKind string
+
+const (
+	ErrorKindPermission ScanErrorKind = "permission denied" // the file or directory could not be opened due to permissions
+	ErrorKindVanished   ScanErrorKind = "vanished"          // the file was removed between being listed and being read
+	ErrorKindRead       ScanErrorKind = "read error"        // any other I/O error while reading
+)
+
+// ScanError describes a file or directory that was skipped because it could not be read.
+type ScanError struct {
+	Path    string        // Path relative to the scan root
+	Kind    ScanErrorKind // Classification of the error
+	Message string        // The underlying error message
+}
+
 // CodebaseReport is the final output structure containing all analysis results.
 type CodebaseReport struct {
 	LanguageMetrics    []LanguageMetricsReport
 	FileMetrics        []FileMetricsReport
 	DirMetrics         []DirMetricsReport
+	ClassMetrics       []ClassMetricsReport // Totals per FileClass, always in the order first-party, test, documentation, generated, vendored
+	TestMetrics        TestMetrics          // Production and test code per language and top-level directory
 	CodebaseMetrics    CodebaseMetrics
 	AnnotationMetrics  AnnotationMetrics
+	GeneratedMetrics   GeneratedMetrics // Generated files, left out of every other metric unless IncludeGeneratedFlag is set
 	DependencyMetrics  DependencyMetrics
+	GoMetrics          GoMetrics         // Only set if GoMetricsFlag is true
+	LongestFunctions   []FunctionMetrics // The 25 longest functions in the codebase (only set if FunctionMetricsFlag is true)
+	DuplicateMetrics   DuplicateMetrics  // Only set if DuplicatesFlag is true
+	GitMetrics         GitMetrics
 	PerformanceMetrics PerformanceMetrics
+	Errors             []ScanError // Files and directories skipped because they could not be read
+	Incomplete         bool        // True if the scan was canceled or timed out before every file was counted
 }
@@ -7,19 +7,35 @@ import (
 	"time"
 )
 
+// isBinary reports whether name has the extension of a binary format. Binary files without one of
+// these extensions are caught by sniffing their content instead (see looksBinary).
 func isBinary(name string) bool {
-	ext := strings.ToLower(filepath.Ext(name))
-	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".class", ".o", ".obj", ".pdf", ".exe":
-		return true
-	default:
-		return false
-	}
+	_, ok := binaryExtensions[strings.ToLower(filepath.Ext(name))]
+	return ok
 }
 
-func excludeDir(name string) bool {
-	// using a map for O(1) lookups (instead of a slice with O(n) lookups)
-	var excludedDirsFromScan = map[string]struct{}{
+// using maps for O(1) lookups (instead of a slice with O(n) lookups)
+var (
+	binaryExtensions = map[string]struct{}{
+		// images
+		".jpg": {}, ".jpeg": {}, ".png": {}, ".gif": {}, ".bmp": {}, ".ico": {}, ".icns": {}, ".tif": {}, ".tiff": {},
+		".webp": {}, ".avif": {}, ".heic": {}, ".psd": {},
+		// audio and video
+		".mp3": {}, ".mp4": {}, ".m4a": {}, ".wav": {}, ".flac": {}, ".ogg": {}, ".avi": {}, ".mov": {}, ".mkv": {}, ".webm": {},
+		// fonts
+		".woff": {}, ".woff2": {}, ".ttf": {}, ".otf": {}, ".eot": {},
+		// archives and packages
+		".zip": {}, ".gz": {}, ".tgz": {}, ".bz2": {}, ".xz": {}, ".zst": {}, ".7z": {}, ".rar": {}, ".tar": {},
+		".jar": {}, ".war": {}, ".whl": {}, ".deb": {}, ".rpm": {}, ".dmg": {}, ".iso": {}, ".apk": {},
+		// compiled code and objects
+		".class": {}, ".o": {}, ".obj": {}, ".a": {}, ".lib": {}, ".so": {}, ".dylib": {}, ".dll": {}, ".exe": {},
+		".wasm": {}, ".pyc": {}, ".pyo": {}, ".pyd": {}, ".beam": {}, ".elc": {},
+		// documents and databases
+		".pdf": {}, ".doc": {}, ".docx": {}, ".xls": {}, ".xlsx": {}, ".ppt": {}, ".pptx": {},
+		".db": {}, ".sqlite": {}, ".sqlite3": {},
+	}
+
+	defaultExcludedDirs = map[string]struct{}{
 		".git":         {},
 		"node_modules": {},
 		"vendor":       {},
@@ -32,12 +48,7 @@ func excludeDir(name string) bool {
 		".cache":       {},
 	}
 
-	_, ok := excludedDirsFromScan[name]
-	return ok
-}
-
-func excludeFile(name string) bool {
-	var excludedFilesFromScan = map[string]struct{}{
+	defaultExcludedFiles = map[string]struct{}{
 		".DS_Store":         {},
 		"desktop.ini":       {},
 		".gitignore":        {},
@@ -45,8 +56,15 @@ func excludeFile(name string) bool {
 		"yarn.lock":         {},
 		"go.sum":            {},
 	}
+)
 
-	_, ok := excludedFilesFromScan[name]
+func excludeDir(name string) bool {
+	_, ok := defaultExcludedDirs[name]
+	return ok
+}
+
+func excludeFile(name string) bool {
+	_, ok := defaultExcludedFiles[name]
 	return ok
 }
 